@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/td0m/doorman/db"
 	pb "github.com/td0m/doorman/gen/go"
 	"github.com/td0m/doorman/server"
 )
@@ -22,7 +23,13 @@ func run() error {
 		return fmt.Errorf("delete failed: %w", err)
 	}
 
-	s := server.NewDoorman(conn)
+	s := server.NewDoorman(db.NewPostgres(conn))
+
+	for _, typ := range []string{"user", "post"} {
+		if _, err := s.UpsertType(ctx, &pb.UpsertTypeRequest{Id: typ}); err != nil {
+			return fmt.Errorf("upsert type failed: %w", err)
+		}
+	}
 
 	_, _ = s.UpsertRole(ctx, &pb.UpsertRoleRequest{
 		Id:    "post:viewer",
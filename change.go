@@ -12,7 +12,17 @@ type Change struct {
 	ID        string
 	Type      string
 	Payload   json.RawMessage
+	Status    string
 	CreatedAt time.Time
+	// position in the log of the tenant, in the order the changes were committed. Assigned by the store.
+	Seq int64
+
+	// the principal that made the change, empty if authentication is disabled or it was made by doorman itself
+	Actor string
+	// why the change was made, e.g. a ticket id, as given by the client
+	Reason string
+	// the id of the client request that made the change
+	RequestID string
 }
 
 func TuplesToChanges(ts []Tuple, created bool) []Change {
@@ -1,19 +1,33 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/rs/xid"
 	pb "github.com/td0m/doorman/gen/go"
+	"golang.org/x/exp/slices"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
 )
 
 var usage = `doorman {{version}}
@@ -23,10 +37,32 @@ usage:
   doorman command [options]
 
 commands:
-	grant          grants subject access to an object via a role.
+	grant          grants subject access to an object via a role, --expires-in revokes it after a duration, --condition only applies it when the expression holds.
 	revoke         revokes subject access to an object via a role.
-	check          checks if the subject can access the object via specified verb.
-	roles upsert   creates or updates a role.
+	check          checks if the subject can access the object via specified verb, --explain shows how, --context key=value is used by conditions, --at checks as of a past time or a duration ago.
+	list-subjects  lists subjects that can access the object via specified verb, --transitive expands groups.
+	roles upsert   creates or updates a role, --includes gives it the verbs of other roles too, --deny takes its verbs away instead, --parent makes its subjects children that the verbs flow to.
+	types list     lists the registered object types.
+	types upsert   registers an object type, --nestable makes the members of its objects inherit their permissions.
+	types remove   removes an object type that is no longer used.
+	watch          prints changes as they are committed, optionally after the given change.
+	export         writes every type, role and tuple as JSON lines, to stdout unless a file is given.
+	import         loads the JSON lines written by export, from stdin unless a file is given, --replace removes everything else.
+	plan           prints how the roles and tuples differ from a YAML or JSON manifest, --prune includes the ones not in it.
+	apply          makes the roles and tuples match a YAML or JSON manifest, --prune removes the ones not in it.
+	audit          lists who changed what and why, --actor, --subject and --object filter it, --since and --until take a time or a duration ago.
+	rebuild-cache  rebuilds the cache from the changes.
+
+options:
+	--reason       records why on the changes any command makes, e.g. a ticket id.
+
+environment:
+	DOORMAN_HOST     the address of the server, localhost:13335 by default.
+	DOORMAN_TENANT   the tenant every command is run for, the default one if not set.
+	DOORMAN_API_KEY  the api key to authenticate with.
+	DOORMAN_TOKEN    the bearer token to authenticate with.
+	DOORMAN_CA       connects over tls, trusting the server certificate if it is signed by this CA.
+	DOORMAN_CERT     the client certificate to authenticate with over tls, along with DOORMAN_KEY.
 `
 
 var (
@@ -39,8 +75,32 @@ func app(ctx context.Context) error {
 		addr = envAddr
 	}
 
+	if tenant := os.Getenv("DOORMAN_TENANT"); len(tenant) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, "doorman-tenant", tenant)
+	}
+
+	// The reason is recorded on the changes the command makes, e.g. doorman grant ... --reason TICKET-123
+	if i := slices.Index(os.Args, "--reason"); i > 1 && i+1 < len(os.Args) {
+		ctx = metadata.AppendToOutgoingContext(ctx, "doorman-reason", os.Args[i+1])
+		os.Args = slices.Delete(slices.Clone(os.Args), i, i+2)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", xid.New().String())
+
+	if key := os.Getenv("DOORMAN_API_KEY"); len(key) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", key)
+	}
+
+	if token := os.Getenv("DOORMAN_TOKEN"); len(token) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+
+	creds, err := transportCredentials()
+	if err != nil {
+		return fmt.Errorf("loading tls credentials failed: %w", err)
+	}
+
 	// Set up a connection to the server.
-	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(creds))
 	if err != nil {
 		return fmt.Errorf("grpc.Dial failed: %w", err)
 	}
@@ -51,26 +111,66 @@ func app(ctx context.Context) error {
 	cmd := os.Args[1]
 	switch cmd {
 	case "check":
-		if len(os.Args) != 5 {
-			return errors.New("usage: check [subject] [verb] [object]")
+		usage := errors.New("usage: check [subject] [verb] [object] [--explain] [--context key=value]... [--at time]")
+		if len(os.Args) < 5 {
+			return usage
 		}
 		subject, verb, object := os.Args[2], os.Args[3], os.Args[4]
 
-		res, err := srv.Check(ctx, &pb.CheckRequest{
+		req := &pb.CheckRequest{
 			Subject: subject,
 			Verb:    verb,
 			Object:  object,
-		})
+			Context: map[string]*structpb.Value{},
+		}
+		var at *timestamppb.Timestamp
+		for args := os.Args[5:]; len(args) > 0; args = args[1:] {
+			switch {
+			case args[0] == "--explain":
+				req.Explain = true
+			case args[0] == "--context" && len(args) > 1:
+				key, value, ok := strings.Cut(args[1], "=")
+				if !ok {
+					return usage
+				}
+				req.Context[key] = parseContextValue(value)
+				args = args[1:]
+			case args[0] == "--at" && len(args) > 1:
+				t, err := parseTime(args[1])
+				if err != nil {
+					return err
+				}
+				at = timestamppb.New(t)
+				args = args[1:]
+			default:
+				return usage
+			}
+		}
+
+		var res *pb.CheckResponse
+		var err error
+		if at != nil {
+			res, err = srv.CheckAt(ctx, &pb.CheckAtRequest{Subject: subject, Verb: verb, Object: object, At: at, Context: req.Context})
+		} else {
+			res, err = srv.Check(ctx, req)
+		}
 		if err != nil {
 			return err
 		}
 
-		if res.Success {
+		switch res.Decision {
+		case pb.Decision_ALLOWED:
 			fmt.Println("✅")
-		} else {
+		case pb.Decision_MISSING_CONTEXT:
+			fmt.Println("? missing context: " + strings.Join(res.MissingContext, ", "))
+		default:
 			fmt.Println("X")
 		}
 
+		if res.Role != nil {
+			printPath(subject, verb, res.Path, res.Role)
+		}
+
 	case "revoke":
 		if len(os.Args) != 5 {
 			return errors.New("usage: revoke [subject] [role] [object]")
@@ -88,16 +188,36 @@ func app(ctx context.Context) error {
 		fmt.Println(res)
 
 	case "grant":
-		if len(os.Args) != 5 {
-			return errors.New("usage: grant [subject] [role] [object]")
+		usage := errors.New("usage: grant [subject] [role] [object] [--expires-in duration] [--condition expression]")
+		if len(os.Args) < 5 {
+			return usage
 		}
 		subject, role, object := os.Args[2], os.Args[3], os.Args[4]
 
-		res, err := srv.Grant(ctx, &pb.GrantRequest{
+		req := &pb.GrantRequest{
 			Subject: subject,
 			Role:    role,
 			Object:  object,
-		})
+		}
+		for args := os.Args[5:]; len(args) > 0; args = args[2:] {
+			if len(args) < 2 {
+				return usage
+			}
+			switch args[0] {
+			case "--expires-in":
+				d, err := time.ParseDuration(args[1])
+				if err != nil {
+					return fmt.Errorf("invalid duration: %w", err)
+				}
+				req.ExpiresAt = timestamppb.New(time.Now().Add(d))
+			case "--condition":
+				req.Condition = args[1]
+			default:
+				return usage
+			}
+		}
+
+		res, err := srv.Grant(ctx, req)
 		if err != nil {
 			return err
 		}
@@ -117,12 +237,267 @@ func app(ctx context.Context) error {
 		}
 		printRelations(res.Items)
 
+	case "list-subjects":
+		transitive := len(os.Args) == 5 && os.Args[4] == "--transitive"
+		if len(os.Args) != 4 && !transitive {
+			return errors.New("usage: list-subjects [object] [verb] [--transitive]")
+		}
+		object, verb := os.Args[2], os.Args[3]
+
+		req := &pb.ListSubjectsRequest{
+			Object:     object,
+			Verb:       verb,
+			Transitive: transitive,
+		}
+		for {
+			res, err := srv.ListSubjects(ctx, req)
+			if err != nil {
+				return err
+			}
+			printRelations(res.Items)
+
+			if res.PaginationToken == nil {
+				break
+			}
+			req.PaginationToken = res.PaginationToken
+		}
+
+	case "watch":
+		if len(os.Args) > 3 {
+			return errors.New("usage: watch [after]")
+		}
+
+		req := &pb.WatchRequest{}
+		if len(os.Args) == 3 {
+			req.After = &os.Args[2]
+		}
+
+		// Not limited by the default timeout
+		stream, err := srv.Watch(context.WithoutCancel(ctx), req)
+		if err != nil {
+			return err
+		}
+		for {
+			c, err := stream.Recv()
+			if err != nil {
+				return err
+			}
+			fmt.Println(c.Id, c.Type, c.CreatedAt.AsTime().Format(time.RFC3339))
+		}
+
+	case "export":
+		if len(os.Args) > 3 {
+			return errors.New("usage: export [file]")
+		}
+
+		out := os.Stdout
+		if len(os.Args) == 3 {
+			f, err := os.Create(os.Args[2])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+
+		// Not limited by the default timeout
+		stream, err := srv.Export(context.WithoutCancel(ctx), &pb.ExportRequest{})
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(out)
+		for {
+			record, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			line, err := protojson.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("protojson.Marshal failed: %w", err)
+			}
+			w.Write(line)
+			w.WriteByte('\n')
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+	case "import":
+		usage := errors.New("usage: import [file] [--replace]")
+		args := os.Args[2:]
+		mode := pb.ImportMode_MERGE
+		if i := slices.Index(args, "--replace"); i >= 0 {
+			mode = pb.ImportMode_REPLACE
+			args = slices.Delete(slices.Clone(args), i, i+1)
+		}
+		if len(args) > 1 {
+			return usage
+		}
+
+		in := os.Stdin
+		if len(args) == 1 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		// Not limited by the default timeout
+		stream, err := srv.Import(context.WithoutCancel(ctx))
+		if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(nil, 1024*1024)
+		for n := 1; scanner.Scan(); n++ {
+			if len(strings.TrimSpace(scanner.Text())) == 0 {
+				continue
+			}
+			record := &pb.Record{}
+			if err := protojson.Unmarshal(scanner.Bytes(), record); err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			if err := stream.Send(&pb.ImportRequest{Mode: mode, Record: record}); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		res, err := stream.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("imported %d types, %d roles and %d tuples\n", res.Types, res.Roles, res.Tuples)
+
+	case "plan", "apply":
+		usage := fmt.Errorf("usage: %s [manifest] [--prune]", cmd)
+		args := os.Args[2:]
+		prune := false
+		if i := slices.Index(args, "--prune"); i >= 0 {
+			prune = true
+			args = slices.Delete(slices.Clone(args), i, i+1)
+		}
+		if len(args) != 1 {
+			return usage
+		}
+
+		manifest, err := readManifest(args[0])
+		if err != nil {
+			return err
+		}
+
+		res, err := srv.Apply(ctx, &pb.ApplyRequest{Manifest: manifest, Prune: prune, DryRun: cmd == "plan"})
+		if err != nil {
+			return err
+		}
+		printDiffs(res.Diffs)
+
+	case "audit":
+		usage := errors.New("usage: audit [--actor subject] [--subject subject] [--object object] [--since time] [--until time]")
+
+		req := &pb.AuditRequest{}
+		for args := os.Args[2:]; len(args) > 0; args = args[2:] {
+			if len(args) < 2 {
+				return usage
+			}
+			switch args[0] {
+			case "--actor":
+				req.Actor = &args[1]
+			case "--subject":
+				req.Subject = &args[1]
+			case "--object":
+				req.Object = &args[1]
+			case "--since", "--until":
+				t, err := parseTime(args[1])
+				if err != nil {
+					return err
+				}
+				if args[0] == "--since" {
+					req.CreatedAfter = timestamppb.New(t)
+				} else {
+					req.CreatedBefore = timestamppb.New(t)
+				}
+			default:
+				return usage
+			}
+		}
+
+		var changes []*pb.Change
+		for {
+			res, err := srv.Audit(ctx, req)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, res.Items...)
+
+			if res.PaginationToken == nil {
+				break
+			}
+			req.PaginationToken = res.PaginationToken
+		}
+		printAudit(changes)
+
 	case "rebuild-cache":
 		_, err := srv.RebuildCache(ctx, &pb.RebuildCacheRequest{})
 		if err != nil {
 			return err
 		}
 
+	case "types":
+		if len(os.Args) < 3 {
+			return errors.New("usage: types [list|upsert|remove]")
+		}
+		os.Args = os.Args[1:]
+		switch os.Args[1] {
+		case "list":
+			if len(os.Args) != 2 {
+				return errors.New("usage: types list")
+			}
+
+			res, err := srv.ListTypes(ctx, &pb.ListTypesRequest{})
+			if err != nil {
+				return err
+			}
+
+			for _, t := range res.Items {
+				if t.Nestable {
+					fmt.Println(t.Id, "(nestable)")
+				} else {
+					fmt.Println(t.Id)
+				}
+			}
+		case "upsert":
+			usage := errors.New("usage: types upsert [id] [--nestable]")
+			if len(os.Args) != 3 && len(os.Args) != 4 {
+				return usage
+			}
+			nestable := len(os.Args) == 4
+			if nestable && os.Args[3] != "--nestable" {
+				return usage
+			}
+
+			if _, err := srv.UpsertType(ctx, &pb.UpsertTypeRequest{Id: os.Args[2], Nestable: nestable}); err != nil {
+				return fmt.Errorf("upsert failed: %w", err)
+			}
+		case "remove":
+			if len(os.Args) != 3 {
+				return errors.New("usage: types remove [id]")
+			}
+
+			if _, err := srv.RemoveType(ctx, &pb.RemoveTypeRequest{Id: os.Args[2]}); err != nil {
+				return fmt.Errorf("remove failed: %w", err)
+			}
+		default:
+			return fmt.Errorf("invalid command: %s", os.Args[1])
+		}
+
 	case "roles":
 		os.Args = os.Args[1:]
 		switch os.Args[1] {
@@ -139,15 +514,34 @@ func app(ctx context.Context) error {
 			printRoles(res.Items)
 		case "upsert":
 			if len(os.Args) < 3 {
-				return errors.New("usage: roles create [id] [verb1] ... [verbN]")
+				return errors.New("usage: roles upsert [id] [verb1] ... [verbN] [--includes role1,...,roleN] [--deny] [--parent]")
 			}
 			id, verbs := os.Args[2], os.Args[3:]
 
+			deny, parent := false, false
+			if i := slices.Index(verbs, "--deny"); i >= 0 {
+				deny = true
+				verbs = slices.Delete(slices.Clone(verbs), i, i+1)
+			}
+			if i := slices.Index(verbs, "--parent"); i >= 0 {
+				parent = true
+				verbs = slices.Delete(slices.Clone(verbs), i, i+1)
+			}
+
+			var includes []string
+			if i := slices.Index(verbs, "--includes"); i >= 0 && i+1 < len(verbs) {
+				includes = strings.Split(verbs[i+1], ",")
+				verbs = slices.Delete(slices.Clone(verbs), i, i+2)
+			}
+
 			fmt.Println("role", id, verbs)
 
 			role, err := srv.UpsertRole(ctx, &pb.UpsertRoleRequest{
-				Id:    id,
-				Verbs: verbs,
+				Id:       id,
+				Verbs:    verbs,
+				Includes: includes,
+				Deny:     deny,
+				Parent:   parent,
 			})
 			if err != nil {
 				return fmt.Errorf("upsert failed: %w", err)
@@ -161,6 +555,41 @@ func app(ctx context.Context) error {
 	return nil
 }
 
+// readManifest reads a YAML manifest, or a JSON one as JSON is valid YAML too.
+// The fields are named the same as in the protobuf messages, e.g. expires_at.
+func readManifest(path string) (*pb.Manifest, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw any
+	if err := yaml.Unmarshal(bs, &raw); err != nil {
+		return nil, fmt.Errorf("parsing manifest failed: %w", err)
+	}
+	bs, err = json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest failed: %w", err)
+	}
+
+	manifest := &pb.Manifest{}
+	if err := protojson.Unmarshal(bs, manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest failed: %w", err)
+	}
+	return manifest, nil
+}
+
+// parseContextValue parses bools and numbers, anything else is a string.
+func parseContextValue(s string) *structpb.Value {
+	if b, err := strconv.ParseBool(s); err == nil {
+		return structpb.NewBoolValue(b)
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return structpb.NewNumberValue(n)
+	}
+	return structpb.NewStringValue(s)
+}
+
 func emojify(id string) string {
 	parts := strings.SplitN(id, ":", 2)
 	if len(parts) == 1 {
@@ -185,6 +614,19 @@ func printAttrs(attrs map[string]any) {
 	}
 }
 
+func printPath(subject, verb string, path []*pb.Connection, role *pb.Role) {
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+
+	fmt.Println(emojify(subject))
+	for i, conn := range path {
+		line := strings.Repeat("   ", i) + "└─ " + muted.Render(conn.Role) + " " + emojify(conn.Object)
+		if i == len(path)-1 {
+			line += muted.Render(fmt.Sprintf(" (%s via %s)", verb, role.Id))
+		}
+		fmt.Println(line)
+	}
+}
+
 func printRelations(rs []*pb.Relation) {
 	rows := [][]string{}
 	for _, r := range rs {
@@ -206,14 +648,92 @@ func printRelations(rs []*pb.Relation) {
 	fmt.Println(table.Render())
 }
 
+func printDiffs(diffs []*pb.Diff) {
+	if len(diffs) == 0 {
+		fmt.Println("no changes")
+		return
+	}
+
+	styles := map[pb.DiffType]lipgloss.Style{
+		pb.DiffType_ADDED:   lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+		pb.DiffType_CHANGED: lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+		pb.DiffType_REMOVED: lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+	}
+	signs := map[pb.DiffType]string{pb.DiffType_ADDED: "+", pb.DiffType_CHANGED: "~", pb.DiffType_REMOVED: "-"}
+
+	for _, d := range diffs {
+		var line string
+		switch item := d.Item.(type) {
+		case *pb.Diff_Role:
+			line = fmt.Sprintf("role %s [%s]", item.Role.Id, strings.Join(item.Role.Verbs, ", "))
+		case *pb.Diff_Tuple:
+			t := item.Tuple
+			line = fmt.Sprintf("tuple (%s, %s, %s)", t.Subject, t.Role, t.Object)
+			if t.ExpiresAt != nil {
+				line += " expires " + t.ExpiresAt.AsTime().Format(time.RFC3339)
+			}
+			if t.Condition != "" {
+				line += " if " + t.Condition
+			}
+		}
+		fmt.Println(styles[d.Type].Render(signs[d.Type] + " " + line))
+	}
+}
+
+func printAudit(cs []*pb.Change) {
+	rows := [][]string{}
+	for _, c := range cs {
+		var change string
+		switch payload := c.Payload.(type) {
+		case *pb.Change_Tuple:
+			t := payload.Tuple
+			change = fmt.Sprintf("%s (%s, %s, %s)", c.Type, t.Subject, t.Role, t.Object)
+		case *pb.Change_Role:
+			change = fmt.Sprintf("%s %s [%s]", c.Type, payload.Role.Id, strings.Join(payload.Role.Verbs, ", "))
+		case *pb.Change_ObjectType:
+			change = fmt.Sprintf("%s %s", c.Type, payload.ObjectType.Id)
+			if payload.ObjectType.Nestable {
+				change += " (nestable)"
+			}
+		}
+		rows = append(rows, []string{c.CreatedAt.AsTime().Local().Format(time.DateTime), emojify(c.Actor), change, c.Reason, c.RequestId})
+	}
+	table := table.New().
+		Border(lipgloss.NormalBorder()).
+		Headers("Time", "Actor", "Change", "Reason", "Request").
+		StyleFunc(func(row, _ int) lipgloss.Style {
+			switch row {
+			case 0:
+				return lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true).Padding(0, 1)
+			default:
+				return lipgloss.NewStyle().Padding(0, 1)
+			}
+		}).
+		Rows(rows...)
+
+	fmt.Println(table.Render())
+}
+
+// parseTime parses either a time such as 2024-01-02T15:04:05Z, or a duration ago such as 24h.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339 or a duration ago", s)
+	}
+	return time.Now().Add(-d), nil
+}
+
 func printRoles(rs []*pb.Role) {
 	rows := [][]string{}
 	for _, r := range rs {
-		rows = append(rows, []string{emojify(r.Id), strings.Join(r.Verbs, ", ")})
+		rows = append(rows, []string{emojify(r.Id), strings.Join(r.Verbs, ", "), strings.Join(r.Includes, ", "), strconv.FormatBool(r.Deny), strconv.FormatBool(r.Parent)})
 	}
 	table := table.New().
 		Border(lipgloss.NormalBorder()).
-		Headers("Role", "Verbs").
+		Headers("Role", "Verbs", "Includes", "Deny", "Parent").
 		StyleFunc(func(row, _ int) lipgloss.Style {
 			switch row {
 			case 0:
@@ -227,6 +747,38 @@ func printRoles(rs []*pb.Role) {
 	fmt.Println(table.Render())
 }
 
+// transportCredentials connects over tls if DOORMAN_CA or DOORMAN_CERT is set, in plain text otherwise.
+func transportCredentials() (credentials.TransportCredentials, error) {
+	caFile, certFile, keyFile := os.Getenv("DOORMAN_CA"), os.Getenv("DOORMAN_CERT"), os.Getenv("DOORMAN_KEY")
+	if caFile == "" && certFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA failed: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls.LoadX509KeyPair failed: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(config), nil
+}
+
 func main() {
 	usage = strings.Replace(usage, "{{version}}", "v0", 1)
 	if len(os.Args) < 2 {
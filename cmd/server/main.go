@@ -1,7 +1,12 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
@@ -10,21 +15,37 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/td0m/doorman"
+	"github.com/td0m/doorman/db"
 	pb "github.com/td0m/doorman/gen/go"
 	"github.com/td0m/doorman/server"
 	"golang.org/x/exp/slog"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 )
 
 func run() error {
 	var noRebuild bool
-	flag.BoolVar(&noRebuild, "no-rebuild-on-start", false, "setting this to true will prevent rebuilding cache when the server is started.")
+	var storeName, sqlitePath string
+	var sweepInterval time.Duration
+	var apiKeysFile, tlsCert, tlsKey, clientCA, admins string
+	flag.BoolVar(&noRebuild, "no-rebuild-on-start", false, "setting this to true will prevent rebuilding cache when the server is started, the cache is loaded from the store instead.")
+	flag.StringVar(&storeName, "store", "postgres", "where roles, tuples and changes are stored: postgres, sqlite or memory.")
+	flag.StringVar(&sqlitePath, "sqlite-path", "doorman.db", "path to the database file, used with -store=sqlite.")
+	flag.DurationVar(&sweepInterval, "sweep-interval", time.Minute, "how often expired grants are revoked.")
+	flag.StringVar(&apiKeysFile, "api-keys-file", "", "path to a file of principal:key lines, the api keys callers can authenticate with.")
+	flag.StringVar(&tlsCert, "tls-cert", "", "path to the certificate the server is served with over tls.")
+	flag.StringVar(&tlsKey, "tls-key", "", "path to the key of -tls-cert.")
+	flag.StringVar(&admins, "admins", "", "comma separated subjects, e.g. user:alice, granted doorman:admin on doorman:system on start. Enables authorization: grants, revokes and the other writes require the manage verb on the object or on doorman:system. Requires authentication.")
+	flag.StringVar(&clientCA, "client-ca", "", "path to the CA certificates client certificates are verified against, callers can authenticate with them. Requires -tls-cert.")
 	flag.Parse()
 
 	ctx := context.Background()
@@ -41,21 +62,63 @@ func run() error {
 		return fmt.Errorf("net.Listen failed: %w", err)
 	}
 
-	conn, err := pgxpool.New(ctx, "")
+	store, err := openStore(ctx, storeName, sqlitePath)
 	if err != nil {
-		return fmt.Errorf("pgxpool.New failed: %w", err)
+		return fmt.Errorf("opening store failed: %w", err)
 	}
 
-	srv := server.NewDoorman(conn)
+	srv := server.NewDoorman(store)
+
+	if err := srv.LoadCache(ctx); err != nil {
+		return fmt.Errorf("loading cache on startup failed: %w", err)
+	}
 
 	if !noRebuild {
-		_, err := srv.RebuildCache(ctx, &pb.RebuildCacheRequest{})
-		if err != nil {
-			return fmt.Errorf("rebuilding cache on startup failed: %w", err)
+		if err := srv.RebuildAllCaches(ctx); err != nil {
+			return fmt.Errorf("rebuilding caches on startup failed: %w", err)
+		}
+	}
+
+	tlsConfig, err := loadTLSConfig(tlsCert, tlsKey, clientCA)
+	if err != nil {
+		return fmt.Errorf("loading tls config failed: %w", err)
+	}
+
+	authenticators, err := loadAuthenticators(apiKeysFile, clientCA)
+	if err != nil {
+		return fmt.Errorf("loading authenticators failed: %w", err)
+	}
+
+	var auth *server.Auth
+	if len(authenticators) > 0 {
+		if auth, err = server.NewAuth(authenticators...); err != nil {
+			return fmt.Errorf("server.NewAuth failed: %w", err)
 		}
+	} else {
+		slog.Warn("no api keys, token secret or client CA are configured, authentication is disabled")
 	}
 
-	s := grpc.NewServer()
+	if admins != "" {
+		if auth == nil {
+			return errors.New("-admins requires authentication to be enabled")
+		}
+
+		var subjects []doorman.Object
+		for _, admin := range strings.Split(admins, ",") {
+			subjects = append(subjects, doorman.Object(strings.TrimSpace(admin)))
+		}
+		if err := srv.Bootstrap(ctx, subjects); err != nil {
+			return fmt.Errorf("bootstrapping admins failed: %w", err)
+		}
+		srv.EnableAuthorization()
+	}
+
+	var serverOpts []grpc.ServerOption
+	if auth != nil {
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(auth.UnaryInterceptor()), grpc.ChainStreamInterceptor(auth.StreamInterceptor()))
+	}
+
+	s := grpc.NewServer(serverOpts...)
 	pb.RegisterDoormanServer(s, srv)
 	reflection.Register(s)
 
@@ -65,19 +128,43 @@ func run() error {
 
 	fmt.Printf("Starting server on: %s\n", addr)
 
+	// The gateway calls the grpc server over the same socket, as streaming is not supported in process
+	muxOpts := []runtime.ServeMuxOption{runtime.WithIncomingHeaderMatcher(headerMatcher)}
+	if auth != nil {
+		muxOpts = append(muxOpts, runtime.WithMetadata(auth.GatewayMetadata))
+	}
+	mux := runtime.NewServeMux(muxOpts...)
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if tlsConfig != nil {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(selfTLSConfig(tlsConfig)))}
+	}
+	if err := pb.RegisterDoormanHandlerFromEndpoint(ctx, mux, addr, opts); err != nil {
+		return fmt.Errorf("RegisterDoormanHandlerFromEndpoint failed: %w", err)
+	}
+
+	var gateway http.Handler = mux
+	if auth != nil {
+		gateway = auth.Middleware(mux)
+	}
+
 	go func(sock net.Listener) {
 		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
 				s.ServeHTTP(w, r)
 			} else {
-				mux := runtime.NewServeMux()
-				if err := pb.RegisterDoormanHandlerServer(ctx, mux, srv); err != nil {
-					panic(fmt.Errorf("RegisterDoormanHandlerServer failed: %w", err))
-				}
-				mux.ServeHTTP(w, r)
+				gateway.ServeHTTP(w, r)
 			}
 		})
 
+		if tlsConfig != nil {
+			httpServer := &http.Server{Handler: handler, TLSConfig: tlsConfig}
+			if err := httpServer.ServeTLS(sock, "", ""); err != nil {
+				panic(fmt.Errorf("http.ServeTLS failed: %w", err))
+			}
+			return
+		}
+
 		if err := http.Serve(sock, h2c.NewHandler(handler, &http2.Server{})); err != nil {
 			panic(fmt.Errorf("http.Serve failed: %w", err))
 		}
@@ -87,7 +174,15 @@ func run() error {
 	go func() {
 		for {
 			if err := srv.ProcessChange(); err != nil {
-				slog.Error("failed to process change: %w", err)
+				slog.Error("failed to process change", "err", err)
+			}
+		}
+	}()
+
+	go func() {
+		for range time.Tick(sweepInterval) {
+			if err := srv.RevokeExpired(ctx); err != nil {
+				slog.Error("failed to revoke expired grants", "err", err)
 			}
 		}
 	}()
@@ -96,6 +191,138 @@ func run() error {
 	return nil
 }
 
+// loadTLSConfig returns nil if the server is not served over tls.
+func loadTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, errors.New("-client-ca requires -tls-cert and -tls-key")
+		}
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls.LoadX509KeyPair failed: %w", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA failed: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+		}
+		// Callers can still authenticate otherwise, e.g. the gateway calling the grpc server
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		config.ClientCAs = pool
+	}
+
+	return config, nil
+}
+
+// selfTLSConfig is what the gateway dials the grpc server with, trusting only the server's own certificate,
+// as it is served on an address the certificate may not be issued for.
+func selfTLSConfig(config *tls.Config) *tls.Config {
+	own := config.Certificates[0].Certificate[0]
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], own) {
+				return errors.New("the gateway only trusts the server's own certificate")
+			}
+			return nil
+		},
+	}
+}
+
+// loadAuthenticators returns the authenticators that are configured, none if authentication is disabled.
+// The token secret is read from DOORMAN_TOKEN_SECRET so that it isn't visible in the process list.
+func loadAuthenticators(apiKeysFile, clientCA string) ([]server.Authenticator, error) {
+	var authenticators []server.Authenticator
+
+	if apiKeysFile != "" {
+		keys, err := loadAPIKeys(apiKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading api keys failed: %w", err)
+		}
+		authenticators = append(authenticators, keys)
+	}
+
+	if secret := os.Getenv("DOORMAN_TOKEN_SECRET"); len(secret) > 0 {
+		authenticators = append(authenticators, server.HMACTokens{Secret: []byte(secret)})
+	}
+
+	if clientCA != "" {
+		authenticators = append(authenticators, server.ClientCertificates{})
+	}
+
+	return authenticators, nil
+}
+
+// loadAPIKeys reads principal:key lines, skipping blank ones and # comments.
+func loadAPIKeys(path string) (server.APIKeys, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.Open failed: %w", err)
+	}
+	defer f.Close()
+
+	keys := server.APIKeys{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		principal, key, ok := strings.Cut(line, ":")
+		if !ok || principal == "" || key == "" {
+			return nil, fmt.Errorf("line %d: expected principal:key", n)
+		}
+		keys[key] = principal
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading failed: %w", err)
+	}
+
+	return keys, nil
+}
+
+// headerMatcher forwards the tenant, reason and request id headers of gateway requests as grpc metadata,
+// e.g. Doorman-Tenant: acme.
+func headerMatcher(key string) (string, bool) {
+	for _, forwarded := range []string{server.TenantMetadataKey, server.ReasonMetadataKey, server.RequestIDMetadataKey} {
+		if strings.EqualFold(key, forwarded) {
+			return forwarded, true
+		}
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+func openStore(ctx context.Context, name, sqlitePath string) (db.Store, error) {
+	switch name {
+	case "postgres":
+		conn, err := pgxpool.New(ctx, "")
+		if err != nil {
+			return nil, fmt.Errorf("pgxpool.New failed: %w", err)
+		}
+		return db.NewPostgres(conn), nil
+	case "sqlite":
+		store, err := db.OpenSQLite(ctx, sqlitePath)
+		if err != nil {
+			return nil, fmt.Errorf("db.OpenSQLite failed: %w", err)
+		}
+		return store, nil
+	case "memory":
+		return db.NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown store: %s", name)
+	}
+}
+
 func main() {
 	if err := run(); err != nil {
 		fmt.Println(err)
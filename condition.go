@@ -0,0 +1,463 @@
+package doorman
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+var ErrInvalidCondition = errors.New("invalid condition")
+
+// MaxConditionLength limits the conditions that can be granted, combined ones can be longer.
+const MaxConditionLength = 1024
+
+// Condition restricts a tuple to the checks whose context it holds for, e.g.
+//
+//	mfa && amount <= 100 && in_cidr(ip, "10.0.0.0/8")
+//
+// Numbers, strings and bools can be compared with ==, !=, <, <=, > and >=, and combined with &&, || and !.
+// There are no loops and the only functions are the ones in conditionFuncs, so evaluating always terminates.
+type Condition struct {
+	src    string
+	root   expr
+	fields []string
+}
+
+func ParseCondition(src string) (*Condition, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCondition, err)
+	}
+
+	p := &parser{tokens: tokens, fields: map[string]bool{}}
+	root, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCondition, err)
+	}
+	if p.peek() != "" {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidCondition, p.peek())
+	}
+
+	fields := make([]string, 0, len(p.fields))
+	for f := range p.fields {
+		fields = append(fields, f)
+	}
+	slices.Sort(fields)
+
+	return &Condition{src: src, root: root, fields: fields}, nil
+}
+
+// NotAll builds a condition that holds unless all of the conditions do.
+func NotAll(conditions []string) string {
+	return "!((" + strings.Join(conditions, ") && (") + "))"
+}
+
+func (c *Condition) String() string {
+	return c.src
+}
+
+// Fields are the names of the context fields the condition uses.
+func (c *Condition) Fields() []string {
+	return c.fields
+}
+
+// Evaluate returns the fields missing from the context instead, if there are any.
+// Numbers in the context can be any int or float type.
+func (c *Condition) Evaluate(context map[string]any) (bool, []string, error) {
+	missing := []string{}
+	for _, f := range c.fields {
+		if _, ok := context[f]; !ok {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		return false, missing, nil
+	}
+
+	v, err := c.root.eval(context)
+	if err != nil {
+		return false, nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, nil, fmt.Errorf("condition is a %s, not a bool", typeName(v))
+	}
+	return b, nil, nil
+}
+
+type expr interface {
+	eval(context map[string]any) (any, error)
+}
+
+type literal struct {
+	v any
+}
+
+func (l literal) eval(context map[string]any) (any, error) {
+	return l.v, nil
+}
+
+type field struct {
+	name string
+}
+
+func (f field) eval(context map[string]any) (any, error) {
+	switch v := context[f.name].(type) {
+	case bool, string, float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	default:
+		return nil, fmt.Errorf("%s is a %T, only bools, numbers and strings are supported", f.name, v)
+	}
+}
+
+type not struct {
+	e expr
+}
+
+func (n not) eval(context map[string]any) (any, error) {
+	b, err := evalBool(n.e, context)
+	return !b, err
+}
+
+type binary struct {
+	op   string
+	l, r expr
+}
+
+func (b binary) eval(context map[string]any) (any, error) {
+	if b.op == "&&" || b.op == "||" {
+		l, err := evalBool(b.l, context)
+		if err != nil || l == (b.op == "||") {
+			return l, err
+		}
+		return evalBool(b.r, context)
+	}
+
+	l, err := b.l.eval(context)
+	if err != nil {
+		return nil, err
+	}
+	r, err := b.r.eval(context)
+	if err != nil {
+		return nil, err
+	}
+	if typeName(l) != typeName(r) {
+		return nil, fmt.Errorf("can't compare a %s with a %s", typeName(l), typeName(r))
+	}
+
+	switch b.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+
+	var cmp int
+	switch l := l.(type) {
+	case float64:
+		cmp = compare(l, r.(float64))
+	case string:
+		cmp = compare(l, r.(string))
+	default:
+		return nil, fmt.Errorf("can't use %s on a %s", b.op, typeName(l))
+	}
+
+	switch b.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+type call struct {
+	fn   string
+	args []expr
+}
+
+func (c call) eval(context map[string]any) (any, error) {
+	args := make([]string, len(c.args))
+	for i, arg := range c.args {
+		v, err := arg.eval(context)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s takes strings, not a %s", c.fn, typeName(v))
+		}
+		args[i] = s
+	}
+	return conditionFuncs[c.fn].call(args)
+}
+
+type conditionFunc struct {
+	arity int
+	call  func(args []string) (bool, error)
+}
+
+var conditionFuncs = map[string]conditionFunc{
+	// in_cidr(ip, "10.0.0.0/8") checks if the ip is within the range
+	"in_cidr": {2, func(args []string) (bool, error) {
+		addr, err := netip.ParseAddr(args[0])
+		if err != nil {
+			return false, fmt.Errorf("in_cidr: %w", err)
+		}
+		prefix, err := netip.ParsePrefix(args[1])
+		if err != nil {
+			return false, fmt.Errorf("in_cidr: %w", err)
+		}
+		return prefix.Contains(addr), nil
+	}},
+}
+
+func evalBool(e expr, context map[string]any) (bool, error) {
+	v, err := e.eval(context)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a bool, got a %s", typeName(v))
+	}
+	return b, nil
+}
+
+func compare[T float64 | string](a, b T) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+type parser struct {
+	tokens []string
+	fields map[string]bool
+}
+
+func (p *parser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	if len(p.tokens) > 0 {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+func (p *parser) expect(t string) error {
+	if got := p.next(); got != t {
+		return fmt.Errorf("expected %q, got %q", t, got)
+	}
+	return nil
+}
+
+// or := and ("||" and)*
+func (p *parser) or() (expr, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = binary{op: "||", l: l, r: r}
+	}
+	return l, nil
+}
+
+// and := comparison ("&&" comparison)*
+func (p *parser) and() (expr, error) {
+	l, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		r, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		l = binary{op: "&&", l: l, r: r}
+	}
+	return l, nil
+}
+
+// comparison := unary (op unary)?
+func (p *parser) comparison() (expr, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.peek(); op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return binary{op: op, l: l, r: r}, nil
+	}
+	return l, nil
+}
+
+// unary := "!" unary | "(" or ")" | literal | field | call
+func (p *parser) unary() (expr, error) {
+	t := p.next()
+	switch {
+	case t == "":
+		return nil, errors.New("unexpected end")
+	case t == "!":
+		e, err := p.unary()
+		return not{e}, err
+	case t == "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	case t == "true" || t == "false":
+		return literal{t == "true"}, nil
+	case t[0] == '"':
+		s, err := strconv.Unquote(t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", t)
+		}
+		return literal{s}, nil
+	case t[0] == '-' || t[0] == '.' || unicode.IsDigit(rune(t[0])):
+		n, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", t)
+		}
+		return literal{n}, nil
+	case isIdentStart(rune(t[0])):
+		if p.peek() == "(" {
+			return p.call(t)
+		}
+		p.fields[t] = true
+		return field{t}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", t)
+	}
+}
+
+func (p *parser) call(fn string) (expr, error) {
+	f, ok := conditionFuncs[fn]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", fn)
+	}
+	p.next()
+
+	c := call{fn: fn}
+	for p.peek() != ")" {
+		if len(c.args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		c.args = append(c.args, arg)
+	}
+	p.next()
+
+	if len(c.args) != f.arity {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", fn, f.arity, len(c.args))
+	}
+	return c, nil
+}
+
+func tokenize(src string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		rest := src[i:]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case strings.HasPrefix(rest, "&&"), strings.HasPrefix(rest, "||"),
+			strings.HasPrefix(rest, "=="), strings.HasPrefix(rest, "!="),
+			strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, ">="):
+			tokens = append(tokens, rest[:2])
+			i += 2
+			continue
+		case strings.ContainsRune("!<>(),", c):
+			tokens = append(tokens, rest[:1])
+			i++
+			continue
+		case c == '"':
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, errors.New("unterminated string")
+			}
+			tokens = append(tokens, quoted)
+			i += len(quoted)
+			continue
+		}
+
+		j := i + 1
+		switch {
+		case c == '-' || c == '.' || unicode.IsDigit(c):
+			for j < len(src) && (src[j] == '.' || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+		case isIdentStart(c):
+			for j < len(src) && (isIdentStart(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+		tokens = append(tokens, src[i:j])
+		i = j
+	}
+	return tokens, nil
+}
+
+func isIdentStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -3,45 +3,72 @@ package db
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/td0m/doorman"
 )
 
 type Changes struct {
-	conn querier
+	conn   querier
+	tenant string
 }
 
-func (c Changes) WithTx(tx pgx.Tx) *Changes {
-	return &Changes{conn: tx}
+func (c Changes) WithTx(tx Tx) ChangeStore {
+	return &Changes{conn: tx.(pgx.Tx), tenant: c.tenant}
 }
 
 type ChangeFilter struct {
-	PaginationToken *string `db:"id" op:">"`
-	Status          *string
+	// only the changes committed after the one with this seq
+	After *int64 `db:"seq" op:">"`
+	// only the changes committed until the one with this seq, included
+	Until         *int64 `db:"seq" op:"<="`
+	Type          *string
+	Status        *string
+	CreatedAfter  *time.Time `db:"created_at" op:">="`
+	CreatedBefore *time.Time `db:"created_at" op:"<"`
+	Actor         *string
+	// of the tuple granted or revoked
+	Subject *string `db:"payload->>'subject'"`
+	Object  *string `db:"payload->>'object'"`
+
+	// all matching changes are listed if nil
+	Limit *int `db:"-"`
 }
 
+func (f ChangeFilter) limit() string {
+	if f.Limit == nil {
+		return ""
+	}
+	return "limit " + strconv.Itoa(*f.Limit)
+}
+
+// Add assigns the change the next seq of the tenant. Changes are only added while holding the lock of the tenant,
+// so the seqs follow the order they are committed in. Otherwise two txs would get the same seq, which the index rejects.
 func (cs Changes) Add(ctx context.Context, c doorman.Change) error {
 	query := `
-		insert into changes(id, type, payload) values($1, $2, $3)
+		insert into changes(tenant, seq, id, type, payload, actor, reason, request_id)
+		select $1, coalesce(max(seq), 0) + 1, $2, $3, $4::jsonb, $5, $6, $7
+		from changes
+		where tenant = $1
 	`
 
-	if _, err := cs.conn.Exec(ctx, query, c.ID, c.Type, []byte(c.Payload)); err != nil {
+	if _, err := cs.conn.Exec(ctx, query, cs.tenant, c.ID, c.Type, []byte(c.Payload), c.Actor, c.Reason, c.RequestID); err != nil {
 		return fmt.Errorf("exec failed: %w", err)
 	}
 	return nil
 }
 
 func (cs Changes) List(ctx context.Context, f ChangeFilter) ([]doorman.Change, error) {
-	where, params := filterBy(&f)
+	where, params := filterBy(cs.tenant, &f)
 
 	query := `
-		select id, type, payload, created_at
+		select id, type, payload, status, created_at, seq, actor, reason, request_id
 		from changes
 	` + where + `
-		order by id
-	`
+		order by seq
+	` + f.limit()
 
 	rows, err := cs.conn.Query(ctx, query, params...)
 	if err != nil {
@@ -51,7 +78,7 @@ func (cs Changes) List(ctx context.Context, f ChangeFilter) ([]doorman.Change, e
 	changes := []doorman.Change{}
 	for rows.Next() {
 		change := doorman.Change{}
-		if err := rows.Scan(&change.ID, &change.Type, &change.Payload, &change.CreatedAt); err != nil {
+		if err := rows.Scan(&change.ID, &change.Type, &change.Payload, &change.Status, &change.CreatedAt, &change.Seq, &change.Actor, &change.Reason, &change.RequestID); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		changes = append(changes, change)
@@ -60,19 +87,75 @@ func (cs Changes) List(ctx context.Context, f ChangeFilter) ([]doorman.Change, e
 	return changes, nil
 }
 
+func (cs Changes) LastSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := cs.conn.QueryRow(ctx, `select coalesce(max(seq), 0) from changes where tenant = $1`, cs.tenant).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("query failed: %w", err)
+	}
+	return seq, nil
+}
+
+func (cs Changes) Retrieve(ctx context.Context, id string) (*doorman.Change, error) {
+	query := `
+		select type, payload, status, created_at, seq, actor, reason, request_id
+		from changes
+		where (tenant, id) = ($1, $2)
+	`
+
+	c := doorman.Change{ID: id}
+	err := cs.conn.QueryRow(ctx, query, cs.tenant, id).Scan(&c.Type, &c.Payload, &c.Status, &c.CreatedAt, &c.Seq, &c.Actor, &c.Reason, &c.RequestID)
+	if err == pgx.ErrNoRows {
+		return nil, ErrInvalidChange
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	return &c, nil
+}
+
+func (cs Changes) ClaimPending(ctx context.Context) (doorman.Change, error) {
+	query := `
+		update changes
+		set status='processed'
+		where id in
+		(
+		  select id
+		  from changes
+			where (tenant, status) = ($1, 'pending')
+		  order by random()
+		  for update skip locked
+		  limit 1
+		)
+		returning id, type, payload, status, created_at, seq, actor, reason, request_id
+	`
+
+	var c doorman.Change
+	err := cs.conn.QueryRow(ctx, query, cs.tenant).Scan(&c.ID, &c.Type, &c.Payload, &c.Status, &c.CreatedAt, &c.Seq, &c.Actor, &c.Reason, &c.RequestID)
+	if err == pgx.ErrNoRows {
+		return c, ErrNoChanges
+	}
+	if err != nil {
+		return c, fmt.Errorf("query failed: %w", err)
+	}
+
+	return c, nil
+}
+
 func (cs Changes) SetStatusOfAll(ctx context.Context, status string) error {
 	query := `
 		update changes
-		set status = $1
+		set status = $2
+		where tenant = $1
 	`
 
-	if _, err := cs.conn.Exec(ctx, query, status); err != nil {
+	if _, err := cs.conn.Exec(ctx, query, cs.tenant, status); err != nil {
 		return fmt.Errorf("exec failed: %w", err)
 	}
 
 	return nil
 }
 
-func NewChanges(pool *pgxpool.Pool) Changes {
-	return Changes{pool}
+func NewChanges(conn querier, tenant string) Changes {
+	return Changes{conn: conn, tenant: tenant}
 }
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type querier interface {
@@ -17,9 +19,10 @@ type querier interface {
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 }
 
-func filterBy(filterStruct any) (string, []any) {
-	var filters []string
-	var params []any
+// filterBy filters by the non nil fields of filterStruct, always within the tenant.
+func filterBy(tenant string, filterStruct any) (string, []any) {
+	filters := []string{"tenant = $1"}
+	params := []any{tenant}
 	val := reflect.ValueOf(filterStruct).Elem()
 
 	for i := 0; i < val.NumField(); i++ {
@@ -47,9 +50,72 @@ func filterBy(filterStruct any) (string, []any) {
 		params = append(params, value.Interface())
 	}
 
-	if len(filters) == 0 {
-		return "", params
+	return "WHERE " + strings.Join(filters, " AND "), params
+}
+
+// tenantsQuery lists the tenants with anything in any of the tables.
+const tenantsQuery = `
+	select tenant from types
+	union select tenant from roles
+	union select tenant from tuples
+	union select tenant from changes
+	union select tenant from set_parents
+	union select tenant from set_subsets
+	order by tenant
+`
+
+type Postgres struct {
+	pool   *pgxpool.Pool
+	tenant string
+}
+
+func (p *Postgres) Begin(ctx context.Context) (Tx, error) {
+	return p.pool.Begin(ctx)
+}
+
+func (p *Postgres) Tenant(id string) Store {
+	return &Postgres{pool: p.pool, tenant: id}
+}
+
+func (p *Postgres) Tenants(ctx context.Context) ([]string, error) {
+	rows, err := p.pool.Query(ctx, tenantsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
 	}
+	defer rows.Close()
 
-	return "WHERE " + strings.Join(filters, " AND "), params
+	tenants := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tenants = append(tenants, id)
+	}
+
+	return tenants, rows.Err()
+}
+
+func (p *Postgres) Changes() ChangeStore {
+	return NewChanges(p.pool, p.tenant)
+}
+
+func (p *Postgres) Roles() RoleStore {
+	return NewRoles(p.pool, p.tenant)
+}
+
+func (p *Postgres) Sets() SetStore {
+	return NewSetTables(p.pool, p.tenant)
+}
+
+func (p *Postgres) Tuples() TupleStore {
+	return NewTuples(p.pool, p.tenant)
+}
+
+func (p *Postgres) Types() TypeStore {
+	return NewTypes(p.pool, p.tenant)
+}
+
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{pool: pool}
 }
@@ -0,0 +1,1119 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/td0m/doorman"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+var errTxClosed = errors.New("tx is closed")
+
+type tupleKey struct {
+	subject doorman.Object
+	role    string
+	object  doorman.Object
+}
+
+func keyOf(t doorman.Tuple) tupleKey {
+	return tupleKey{subject: t.Subject, role: t.Role, object: t.Object}
+}
+
+// the parts of a tuple that aren't in its key, only stored if set
+type tupleAttrs struct {
+	expiresAt *time.Time
+	condition string
+}
+
+func newTupleAttrs(t doorman.Tuple) tupleAttrs {
+	attrs := tupleAttrs{condition: t.Condition}
+	if t.ExpiresAt != nil {
+		expiresAt := *t.ExpiresAt
+		attrs.expiresAt = &expiresAt
+	}
+	return attrs
+}
+
+type memoryChange struct {
+	change doorman.Change
+	status string
+}
+
+// Memory is a Store that keeps everything in process memory, i.e. it is lost on restart.
+// Writes made within a tx are kept aside and only applied once it is committed.
+type Memory struct {
+	mu sync.RWMutex
+
+	tuples    map[tupleKey]bool
+	attrs     map[tupleKey]tupleAttrs
+	bySubject map[doorman.Object]map[tupleKey]bool
+	byObject  map[doorman.Object]map[tupleKey]bool
+	roles     map[string]doorman.Role
+	types     map[string]doorman.Type
+	changes   map[string]*memoryChange
+	claimed   map[string]bool
+	// of the last change committed, see doorman.Change.Seq
+	seq     int64
+	parents map[doorman.Object][]doorman.Membership
+	subsets map[doorman.Set][]doorman.Membership
+
+	// held by the tx that locked the tuples
+	lock chan struct{}
+
+	// shared by the tenants, each of them has its own Memory
+	tenants *memoryTenants
+}
+
+type memoryTenants struct {
+	mu sync.Mutex
+	m  map[string]*Memory
+}
+
+func (m *Memory) Begin(ctx context.Context) (Tx, error) {
+	return m.begin(), nil
+}
+
+func (m *Memory) Tenant(id string) Store {
+	m.tenants.mu.Lock()
+	defer m.tenants.mu.Unlock()
+
+	tenant, ok := m.tenants.m[id]
+	if !ok {
+		tenant = newMemory(m.tenants)
+		m.tenants.m[id] = tenant
+	}
+	return tenant
+}
+
+func (m *Memory) Tenants(ctx context.Context) ([]string, error) {
+	m.tenants.mu.Lock()
+	defer m.tenants.mu.Unlock()
+
+	tenants := []string{}
+	for id, tenant := range m.tenants.m {
+		tenant.mu.RLock()
+		if len(tenant.types) > 0 || len(tenant.roles) > 0 || len(tenant.tuples) > 0 || len(tenant.changes) > 0 || len(tenant.parents) > 0 || len(tenant.subsets) > 0 {
+			tenants = append(tenants, id)
+		}
+		tenant.mu.RUnlock()
+	}
+	slices.Sort(tenants)
+
+	return tenants, nil
+}
+
+func (m *Memory) Changes() ChangeStore {
+	return memoryChanges{m: m}
+}
+
+func (m *Memory) Roles() RoleStore {
+	return memoryRoles{m: m}
+}
+
+func (m *Memory) Sets() SetStore {
+	return memorySets{m: m}
+}
+
+func (m *Memory) Tuples() TupleStore {
+	return memoryTuples{m: m}
+}
+
+func (m *Memory) Types() TypeStore {
+	return memoryTypes{m: m}
+}
+
+func (m *Memory) begin() *memoryTx {
+	return &memoryTx{
+		m:       m,
+		added:   map[tupleKey]bool{},
+		removed: map[tupleKey]bool{},
+		attrs:   map[tupleKey]tupleAttrs{},
+		roles:   map[string]*doorman.Role{},
+		types:   map[string]*doorman.Type{},
+		parents: map[doorman.Object][]doorman.Membership{},
+		subsets: map[doorman.Set][]doorman.Membership{},
+	}
+}
+
+// run executes fn within tx, or within a new tx that is committed straight after if tx is nil.
+func (m *Memory) run(ctx context.Context, tx *memoryTx, fn func(tx *memoryTx) error) error {
+	if tx != nil {
+		return fn(tx)
+	}
+
+	tx = m.begin()
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// view returns tx for reading, or an empty tx that only sees committed data if tx is nil.
+func (m *Memory) view(tx *memoryTx) *memoryTx {
+	if tx != nil {
+		return tx
+	}
+	return m.begin()
+}
+
+func NewMemory() *Memory {
+	tenants := &memoryTenants{m: map[string]*Memory{}}
+	m := newMemory(tenants)
+	for _, t := range DefaultTypes {
+		m.types[t.ID] = t
+	}
+	tenants.m[""] = m
+	return m
+}
+
+func newMemory(tenants *memoryTenants) *Memory {
+	return &Memory{
+		tuples:    map[tupleKey]bool{},
+		attrs:     map[tupleKey]tupleAttrs{},
+		bySubject: map[doorman.Object]map[tupleKey]bool{},
+		byObject:  map[doorman.Object]map[tupleKey]bool{},
+		roles:     map[string]doorman.Role{},
+		types:     map[string]doorman.Type{},
+		changes:   map[string]*memoryChange{},
+		claimed:   map[string]bool{},
+		parents:   map[doorman.Object][]doorman.Membership{},
+		subsets:   map[doorman.Set][]doorman.Membership{},
+		lock:      make(chan struct{}, 1),
+		tenants:   tenants,
+	}
+}
+
+type memoryTx struct {
+	m *Memory
+
+	done   bool
+	locked bool
+
+	added   map[tupleKey]bool
+	removed map[tupleKey]bool
+	// of the tuples added within the tx
+	attrs map[tupleKey]tupleAttrs
+	// nil if removed within the tx
+	roles map[string]*doorman.Role
+	types map[string]*doorman.Type
+
+	changes     []doorman.Change
+	claimed     []string
+	statusOfAll *string
+
+	parents map[doorman.Object][]doorman.Membership
+	subsets map[doorman.Set][]doorman.Membership
+}
+
+func (tx *memoryTx) Commit(ctx context.Context) error {
+	if tx.done {
+		return errTxClosed
+	}
+	tx.done = true
+
+	m := tx.m
+	m.mu.Lock()
+	for k := range tx.removed {
+		delete(m.tuples, k)
+		delete(m.attrs, k)
+		delete(m.bySubject[k.subject], k)
+		delete(m.byObject[k.object], k)
+	}
+	for k := range tx.added {
+		m.tuples[k] = true
+		if m.bySubject[k.subject] == nil {
+			m.bySubject[k.subject] = map[tupleKey]bool{}
+		}
+		m.bySubject[k.subject][k] = true
+		if m.byObject[k.object] == nil {
+			m.byObject[k.object] = map[tupleKey]bool{}
+		}
+		m.byObject[k.object][k] = true
+	}
+	for k, attrs := range tx.attrs {
+		if attrs == (tupleAttrs{}) {
+			delete(m.attrs, k)
+		} else {
+			m.attrs[k] = attrs
+		}
+	}
+	for id, role := range tx.roles {
+		if role == nil {
+			delete(m.roles, id)
+		} else {
+			m.roles[id] = *role
+		}
+	}
+	for id, typ := range tx.types {
+		if typ == nil {
+			delete(m.types, id)
+		} else {
+			m.types[id] = *typ
+		}
+	}
+	if tx.statusOfAll != nil {
+		for _, c := range m.changes {
+			c.status = *tx.statusOfAll
+		}
+	}
+	for _, c := range tx.changes {
+		m.seq++
+		c.Seq = m.seq
+		m.changes[c.ID] = &memoryChange{change: c, status: "pending"}
+	}
+	for _, id := range tx.claimed {
+		if c, ok := m.changes[id]; ok {
+			c.status = "processed"
+		}
+		delete(m.claimed, id)
+	}
+	for subject, sets := range tx.parents {
+		m.parents[subject] = sets
+	}
+	for set, sets := range tx.subsets {
+		m.subsets[set] = sets
+	}
+	m.mu.Unlock()
+
+	tx.unlock()
+	return nil
+}
+
+func (tx *memoryTx) Rollback(ctx context.Context) error {
+	if tx.done {
+		return errTxClosed
+	}
+	tx.done = true
+
+	tx.m.mu.Lock()
+	for _, id := range tx.claimed {
+		delete(tx.m.claimed, id)
+	}
+	tx.m.mu.Unlock()
+
+	tx.unlock()
+	return nil
+}
+
+func (tx *memoryTx) lockTuples(ctx context.Context) error {
+	if tx.locked {
+		return nil
+	}
+
+	select {
+	case tx.m.lock <- struct{}{}:
+		tx.locked = true
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (tx *memoryTx) unlock() {
+	if tx.locked {
+		tx.locked = false
+		<-tx.m.lock
+	}
+}
+
+// The methods below read both the committed data and the writes of the tx.
+// Callers must hold at least a read lock on tx.m.mu.
+
+func (tx *memoryTx) hasTuple(k tupleKey) bool {
+	if tx.removed[k] {
+		return false
+	}
+	return tx.added[k] || tx.m.tuples[k]
+}
+
+// neighbours lists the tuples leaving o, or entering it if inverted, in a stable order.
+func (tx *memoryTx) neighbours(o doorman.Object, inverted bool) []tupleKey {
+	index := tx.m.bySubject
+	if inverted {
+		index = tx.m.byObject
+	}
+
+	var keys []tupleKey
+	for k := range index[o] {
+		if !tx.removed[k] {
+			keys = append(keys, k)
+		}
+	}
+	for k := range tx.added {
+		if (!inverted && k.subject == o) || (inverted && k.object == o) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if inverted {
+			a.subject, a.object = a.object, a.subject
+			b.subject, b.object = b.object, b.subject
+		}
+		if a.object != b.object {
+			return a.object < b.object
+		}
+		return a.role < b.role
+	})
+
+	return keys
+}
+
+func (tx *memoryTx) attrsOf(k tupleKey) tupleAttrs {
+	if attrs, ok := tx.attrs[k]; ok {
+		return attrs
+	}
+	return tx.m.attrs[k]
+}
+
+func (tx *memoryTx) tuple(k tupleKey) doorman.Tuple {
+	attrs := tx.attrsOf(k)
+	tuple := doorman.NewTuple(k.subject, k.role, k.object)
+	tuple.ExpiresAt = attrs.expiresAt
+	tuple.Condition = attrs.condition
+	return tuple
+}
+
+func (tx *memoryTx) role(id string) (doorman.Role, bool) {
+	if role, ok := tx.roles[id]; ok {
+		if role == nil {
+			return doorman.Role{}, false
+		}
+		return *role, true
+	}
+	role, ok := tx.m.roles[id]
+	return role, ok
+}
+
+// change returns the change with its status as seen from within the tx.
+func (tx *memoryTx) change(mc memoryChange) doorman.Change {
+	change := mc.change
+	change.Status = mc.status
+	if tx.statusOfAll != nil {
+		change.Status = *tx.statusOfAll
+	}
+	if slices.Contains(tx.claimed, change.ID) {
+		change.Status = "processed"
+	}
+	return change
+}
+
+func (tx *memoryTx) typ(id string) (doorman.Type, bool) {
+	if typ, ok := tx.types[id]; ok {
+		if typ == nil {
+			return doorman.Type{}, false
+		}
+		return *typ, true
+	}
+	typ, ok := tx.m.types[id]
+	return typ, ok
+}
+
+func (tx *memoryTx) roleInUse(id string) bool {
+	for k := range tx.m.tuples {
+		if k.role == id && !tx.removed[k] {
+			return true
+		}
+	}
+	for k := range tx.added {
+		if k.role == id {
+			return true
+		}
+	}
+	return false
+}
+
+// listConnected is the in-memory equivalent of the recursive query in Tuples.ListConnected.
+func (tx *memoryTx) listConnected(subject doorman.Object, inverted bool) []doorman.Path {
+	var paths []doorman.Path
+
+	frontier := []doorman.Path{{}}
+	for len(frontier) > 0 {
+		var next []doorman.Path
+		for _, path := range frontier {
+			for _, h := range tx.hops(subject, path, inverted) {
+				k := h.key
+				to := k.object
+				if inverted {
+					to = k.subject
+				}
+				if to == subject || pathContains(path, to) {
+					continue
+				}
+
+				attrs := tx.attrsOf(k)
+				connected := append(slices.Clone(path), h.via...)
+				connected = append(connected, doorman.Connection{
+					Role:      k.role,
+					Object:    to,
+					ExpiresAt: attrs.expiresAt,
+					Condition: attrs.condition,
+				})
+				next = append(next, connected)
+			}
+		}
+
+		paths = append(paths, next...)
+		frontier = next
+	}
+
+	return paths
+}
+
+type memoryHop struct {
+	// the connection to the subject set the tuple goes through, if any
+	via doorman.Path
+	key tupleKey
+}
+
+// hops lists the tuples the path can continue with.
+// Besides the tuples of its last object, these are the tuples of the subject set of the role it has on it,
+// e.g. from group:eng reached with group:maintainer to the tuples of group:eng#maintainer, or back if inverted.
+func (tx *memoryTx) hops(subject doorman.Object, path doorman.Path, inverted bool) []memoryHop {
+	from := subject
+	if len(path) > 0 {
+		from = path.Object()
+	}
+
+	var hops []memoryHop
+	for _, k := range tx.neighbours(from, inverted) {
+		hops = append(hops, memoryHop{key: k})
+	}
+	if len(path) == 0 {
+		return hops
+	}
+
+	last := path[len(path)-1]
+	if !inverted {
+		if set, ok := last.Object.SubjectSet(last.Role); ok && !pathContains(path, set) {
+			for _, k := range tx.neighbours(set, false) {
+				hops = append(hops, memoryHop{via: doorman.Path{{Object: set}}, key: k})
+			}
+		}
+	} else if obj, role, ok := last.Object.SplitSubjectSet(); ok && !pathContains(path, obj) {
+		for _, k := range tx.neighbours(obj, true) {
+			if k.role == role {
+				hops = append(hops, memoryHop{via: doorman.Path{{Object: obj}}, key: k})
+			}
+		}
+	}
+	return hops
+}
+
+// reachable is the in-memory equivalent of listConnectedTiny.
+// The subject sets on the way are reachable too, starting with the one of role on subject.
+func (tx *memoryTx) reachable(subject doorman.Object, role string) map[doorman.Object]bool {
+	seen := map[doorman.Object]bool{}
+	queue := []doorman.Object{subject}
+	if set, ok := subject.SubjectSet(role); ok {
+		seen[set] = true
+		queue = append(queue, set)
+	}
+	for len(queue) > 0 {
+		o := queue[0]
+		queue = queue[1:]
+		for _, k := range tx.neighbours(o, false) {
+			reached := []doorman.Object{k.object}
+			if set, ok := k.object.SubjectSet(k.role); ok {
+				reached = append(reached, set)
+			}
+			for _, r := range reached {
+				if !seen[r] {
+					seen[r] = true
+					queue = append(queue, r)
+				}
+			}
+		}
+	}
+	return seen
+}
+
+func pathContains(path doorman.Path, o doorman.Object) bool {
+	for _, conn := range path {
+		if conn.Object == o {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryTuples struct {
+	m  *Memory
+	tx *memoryTx
+}
+
+func (t memoryTuples) WithTx(tx Tx) TupleStore {
+	return memoryTuples{m: t.m, tx: tx.(*memoryTx)}
+}
+
+func (t memoryTuples) Lock(ctx context.Context) error {
+	if t.tx == nil {
+		return nil
+	}
+	return t.tx.lockTuples(ctx)
+}
+
+func (t memoryTuples) Add(ctx context.Context, tuple doorman.Tuple) error {
+	return t.m.run(ctx, t.tx, func(tx *memoryTx) error {
+		t.m.mu.RLock()
+		defer t.m.mu.RUnlock()
+
+		k := keyOf(tuple)
+		if tx.hasTuple(k) {
+			return doorman.ErrTupleExists
+		}
+		if _, ok := tx.role(tuple.Role); !ok {
+			return ErrInvalidRole
+		}
+		if tuple.Subject == tuple.Object || tx.reachable(tuple.Object, tuple.Role)[tuple.Subject] {
+			return ErrCycle
+		}
+
+		if tx.removed[k] {
+			delete(tx.removed, k)
+		} else {
+			tx.added[k] = true
+		}
+		tx.attrs[k] = newTupleAttrs(tuple)
+		return nil
+	})
+}
+
+func (t memoryTuples) Remove(ctx context.Context, tuple doorman.Tuple) error {
+	return t.m.run(ctx, t.tx, func(tx *memoryTx) error {
+		t.m.mu.RLock()
+		defer t.m.mu.RUnlock()
+
+		k := keyOf(tuple)
+		if !tx.hasTuple(k) {
+			return doorman.ErrTupleNotFound
+		}
+
+		if tx.added[k] {
+			delete(tx.added, k)
+		} else {
+			tx.removed[k] = true
+		}
+		delete(tx.attrs, k)
+		return nil
+	})
+}
+
+func (t memoryTuples) ListParents(ctx context.Context, subject doorman.Object) ([]doorman.Tuple, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	tx := t.m.view(t.tx)
+
+	var tuples []doorman.Tuple
+	for _, k := range tx.neighbours(subject, false) {
+		tuples = append(tuples, tx.tuple(k))
+	}
+	return tuples, nil
+}
+
+func (t memoryTuples) ListTuplesBetween(ctx context.Context, subject, object doorman.Object) ([]doorman.Tuple, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	tx := t.m.view(t.tx)
+
+	var tuples []doorman.Tuple
+	for _, k := range tx.neighbours(subject, false) {
+		if k.object == object {
+			tuples = append(tuples, tx.tuple(k))
+		}
+	}
+	return tuples, nil
+}
+
+func (t memoryTuples) HasTuplesForType(ctx context.Context, typ string) (bool, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	tx := t.m.view(t.tx)
+
+	ofType := func(k tupleKey) bool {
+		return k.subject.Type() == typ || k.object.Type() == typ
+	}
+	for k := range t.m.tuples {
+		if ofType(k) && !tx.removed[k] {
+			return true, nil
+		}
+	}
+	for k := range tx.added {
+		if ofType(k) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (t memoryTuples) List(ctx context.Context) ([]doorman.Tuple, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	tx := t.m.view(t.tx)
+
+	var tuples []doorman.Tuple
+	for k := range t.m.tuples {
+		if !tx.removed[k] {
+			tuples = append(tuples, tx.tuple(k))
+		}
+	}
+	for k := range tx.added {
+		tuples = append(tuples, tx.tuple(k))
+	}
+	sort.Slice(tuples, func(i, j int) bool {
+		a, b := tuples[i], tuples[j]
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
+		return a.Object < b.Object
+	})
+	return tuples, nil
+}
+
+func (t memoryTuples) ListTuplesForRole(ctx context.Context, role string) ([]doorman.Tuple, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	tx := t.m.view(t.tx)
+
+	var tuples []doorman.Tuple
+	for k := range t.m.tuples {
+		if k.role == role && !tx.removed[k] {
+			tuples = append(tuples, tx.tuple(k))
+		}
+	}
+	for k := range tx.added {
+		if k.role == role {
+			tuples = append(tuples, tx.tuple(k))
+		}
+	}
+	return tuples, nil
+}
+
+func (t memoryTuples) ListExpiredTuples(ctx context.Context, before time.Time) ([]doorman.Tuple, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	tx := t.m.view(t.tx)
+
+	var tuples []doorman.Tuple
+	for k := range t.m.tuples {
+		if !tx.removed[k] && !tx.added[k] {
+			tuples = append(tuples, tx.tuple(k))
+		}
+	}
+	for k := range tx.added {
+		tuples = append(tuples, tx.tuple(k))
+	}
+
+	expired := []doorman.Tuple{}
+	for _, t := range tuples {
+		if t.ExpiresAt != nil && t.ExpiresAt.Before(before) {
+			expired = append(expired, t)
+		}
+	}
+	return expired, nil
+}
+
+func (t memoryTuples) ListConnected(ctx context.Context, subject doorman.Object, inverted bool) ([]doorman.Path, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	return t.m.view(t.tx).listConnected(subject, inverted), nil
+}
+
+func (t memoryTuples) ListSubjectPaths(ctx context.Context, object, after doorman.Object, limit int) ([]doorman.Path, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	connected := t.m.view(t.tx).listConnected(object, true)
+
+	unique := map[doorman.Object]bool{}
+	for _, path := range connected {
+		if sub := path.Object(); sub > after {
+			unique[sub] = true
+		}
+	}
+	subjects := maps.Keys(unique)
+	slices.Sort(subjects)
+
+	listed := func(sub doorman.Object) bool { return sub > after }
+	if len(subjects) > limit {
+		last := subjects[limit-1]
+		listed = func(sub doorman.Object) bool { return sub > after && sub <= last }
+	}
+
+	var paths []doorman.Path
+	for _, path := range connected {
+		if sub := path.Object(); sub.IsWildcard() || listed(sub) {
+			paths = append(paths, path)
+		}
+	}
+	// Stable, so that the shortest paths stay first
+	slices.SortStableFunc(paths, func(a, b doorman.Path) int { return strings.Compare(string(a.Object()), string(b.Object())) })
+
+	return paths, nil
+}
+
+type memoryRoles struct {
+	m  *Memory
+	tx *memoryTx
+}
+
+func (r memoryRoles) WithTx(tx Tx) RoleStore {
+	return memoryRoles{m: r.m, tx: tx.(*memoryTx)}
+}
+
+func (r memoryRoles) Add(ctx context.Context, role doorman.Role) error {
+	return r.m.run(ctx, r.tx, func(tx *memoryTx) error {
+		r.m.mu.RLock()
+		defer r.m.mu.RUnlock()
+
+		if _, ok := tx.role(role.ID); ok {
+			return fmt.Errorf("role %q already exists", role.ID)
+		}
+
+		tx.roles[role.ID] = cloneRole(role)
+		return nil
+	})
+}
+
+func (r memoryRoles) List(ctx context.Context) ([]doorman.Role, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	tx := r.m.view(r.tx)
+
+	ids := map[string]bool{}
+	for id := range r.m.roles {
+		ids[id] = true
+	}
+	for id := range tx.roles {
+		ids[id] = true
+	}
+
+	var roles []doorman.Role
+	for id := range ids {
+		if role, ok := tx.role(id); ok {
+			role = *cloneRole(role)
+			slices.Sort(role.Verbs)
+			roles = append(roles, role)
+		}
+	}
+	slices.SortFunc(roles, func(a, b doorman.Role) int {
+		if a.ID < b.ID {
+			return -1
+		}
+		if a.ID > b.ID {
+			return 1
+		}
+		return 0
+	})
+
+	return roles, nil
+}
+
+func (r memoryRoles) Retrieve(ctx context.Context, id string) (*doorman.Role, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	role, ok := r.m.view(r.tx).role(id)
+	if !ok {
+		return nil, ErrInvalidRole
+	}
+
+	return cloneRole(role), nil
+}
+
+func (r memoryRoles) Remove(ctx context.Context, id string) error {
+	return r.m.run(ctx, r.tx, func(tx *memoryTx) error {
+		r.m.mu.RLock()
+		defer r.m.mu.RUnlock()
+
+		if tx.roleInUse(id) {
+			return fmt.Errorf("role %q is still in use", id)
+		}
+
+		tx.roles[id] = nil
+		return nil
+	})
+}
+
+func (r memoryRoles) Upsert(ctx context.Context, role *doorman.Role) error {
+	return r.m.run(ctx, r.tx, func(tx *memoryTx) error {
+		tx.roles[role.ID] = cloneRole(*role)
+		return nil
+	})
+}
+
+func cloneRole(r doorman.Role) *doorman.Role {
+	r.Verbs = slices.Clone(r.Verbs)
+	if r.Verbs == nil {
+		r.Verbs = []doorman.Verb{}
+	}
+	r.Includes = slices.Clone(r.Includes)
+	return &r
+}
+
+type memoryTypes struct {
+	m  *Memory
+	tx *memoryTx
+}
+
+func (t memoryTypes) WithTx(tx Tx) TypeStore {
+	return memoryTypes{m: t.m, tx: tx.(*memoryTx)}
+}
+
+func (t memoryTypes) List(ctx context.Context) ([]doorman.Type, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	tx := t.m.view(t.tx)
+
+	ids := map[string]bool{}
+	for id := range t.m.types {
+		ids[id] = true
+	}
+	for id := range tx.types {
+		ids[id] = true
+	}
+
+	types := []doorman.Type{}
+	for id := range ids {
+		if typ, ok := tx.typ(id); ok {
+			types = append(types, typ)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].ID < types[j].ID })
+
+	return types, nil
+}
+
+func (t memoryTypes) Retrieve(ctx context.Context, id string) (*doorman.Type, error) {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+
+	typ, ok := t.m.view(t.tx).typ(id)
+	if !ok {
+		return nil, ErrInvalidType
+	}
+
+	return &typ, nil
+}
+
+func (t memoryTypes) Remove(ctx context.Context, id string) error {
+	return t.m.run(ctx, t.tx, func(tx *memoryTx) error {
+		tx.types[id] = nil
+		return nil
+	})
+}
+
+func (t memoryTypes) Upsert(ctx context.Context, typ doorman.Type) error {
+	return t.m.run(ctx, t.tx, func(tx *memoryTx) error {
+		tx.types[typ.ID] = &typ
+		return nil
+	})
+}
+
+type memoryChanges struct {
+	m  *Memory
+	tx *memoryTx
+}
+
+func (c memoryChanges) WithTx(tx Tx) ChangeStore {
+	return memoryChanges{m: c.m, tx: tx.(*memoryTx)}
+}
+
+func (c memoryChanges) Add(ctx context.Context, change doorman.Change) error {
+	return c.m.run(ctx, c.tx, func(tx *memoryTx) error {
+		tx.changes = append(tx.changes, change)
+		return nil
+	})
+}
+
+func (c memoryChanges) List(ctx context.Context, f ChangeFilter) ([]doorman.Change, error) {
+	c.m.mu.RLock()
+	defer c.m.mu.RUnlock()
+
+	tx := c.m.view(c.tx)
+
+	all := make([]memoryChange, 0, len(c.m.changes)+len(tx.changes))
+	for _, mc := range c.m.changes {
+		all = append(all, *mc)
+	}
+	// The changes added within the tx get the seqs they would be committed with
+	for i, change := range tx.changes {
+		change.Seq = c.m.seq + int64(i) + 1
+		all = append(all, memoryChange{change: change, status: "pending"})
+	}
+
+	changes := []doorman.Change{}
+	for _, mc := range all {
+		change := tx.change(mc)
+
+		if f.After != nil && change.Seq <= *f.After {
+			continue
+		}
+		if f.Until != nil && change.Seq > *f.Until {
+			continue
+		}
+		if f.Type != nil && change.Type != *f.Type {
+			continue
+		}
+		if f.Status != nil && change.Status != *f.Status {
+			continue
+		}
+		if f.CreatedAfter != nil && change.CreatedAt.Before(*f.CreatedAfter) {
+			continue
+		}
+		if f.CreatedBefore != nil && !change.CreatedAt.Before(*f.CreatedBefore) {
+			continue
+		}
+		if f.Actor != nil && change.Actor != *f.Actor {
+			continue
+		}
+		if f.Subject != nil || f.Object != nil {
+			var tuple struct {
+				Subject string `json:"subject"`
+				Object  string `json:"object"`
+			}
+			// Role changes have neither, so they never match
+			_ = json.Unmarshal(change.Payload, &tuple)
+			if f.Subject != nil && tuple.Subject != *f.Subject || f.Object != nil && tuple.Object != *f.Object {
+				continue
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Seq < changes[j].Seq })
+
+	if f.Limit != nil && len(changes) > *f.Limit {
+		changes = changes[:*f.Limit]
+	}
+
+	return changes, nil
+}
+
+func (c memoryChanges) LastSeq(ctx context.Context) (int64, error) {
+	c.m.mu.RLock()
+	defer c.m.mu.RUnlock()
+
+	return c.m.seq, nil
+}
+
+func (c memoryChanges) Retrieve(ctx context.Context, id string) (*doorman.Change, error) {
+	c.m.mu.RLock()
+	defer c.m.mu.RUnlock()
+
+	tx := c.m.view(c.tx)
+
+	if mc, ok := c.m.changes[id]; ok {
+		change := tx.change(*mc)
+		return &change, nil
+	}
+	for i, change := range tx.changes {
+		if change.ID == id {
+			change.Seq = c.m.seq + int64(i) + 1
+			change = tx.change(memoryChange{change: change, status: "pending"})
+			return &change, nil
+		}
+	}
+
+	return nil, ErrInvalidChange
+}
+
+func (c memoryChanges) ClaimPending(ctx context.Context) (doorman.Change, error) {
+	var claimed doorman.Change
+	err := c.m.run(ctx, c.tx, func(tx *memoryTx) error {
+		c.m.mu.Lock()
+		defer c.m.mu.Unlock()
+
+		// Picked at random, like postgres does, so that concurrent workers don't contend on the same change
+		var pending []*memoryChange
+		for id, mc := range c.m.changes {
+			if mc.status == "pending" && !c.m.claimed[id] {
+				pending = append(pending, mc)
+			}
+		}
+		if len(pending) == 0 {
+			return ErrNoChanges
+		}
+		mc := pending[rand.Intn(len(pending))]
+
+		c.m.claimed[mc.change.ID] = true
+		tx.claimed = append(tx.claimed, mc.change.ID)
+		claimed = mc.change
+		claimed.Status = "processed"
+		return nil
+	})
+
+	return claimed, err
+}
+
+func (c memoryChanges) SetStatusOfAll(ctx context.Context, status string) error {
+	return c.m.run(ctx, c.tx, func(tx *memoryTx) error {
+		tx.statusOfAll = &status
+		return nil
+	})
+}
+
+type memorySets struct {
+	m  *Memory
+	tx *memoryTx
+}
+
+func (s memorySets) WithTx(tx Tx) SetStore {
+	return memorySets{m: s.m, tx: tx.(*memoryTx)}
+}
+
+func (s memorySets) UpdateParents(ctx context.Context, subject doorman.Object, parents []doorman.Membership) error {
+	return s.m.run(ctx, s.tx, func(tx *memoryTx) error {
+		tx.parents[subject] = slices.Clone(parents)
+		return nil
+	})
+}
+
+func (s memorySets) UpdateSubsets(ctx context.Context, set doorman.Set, subsets []doorman.Membership) error {
+	return s.m.run(ctx, s.tx, func(tx *memoryTx) error {
+		tx.subsets[set] = slices.Clone(subsets)
+		return nil
+	})
+}
+
+func (s memorySets) ListAllParents(ctx context.Context) (map[doorman.Object][]doorman.Membership, error) {
+	s.m.mu.RLock()
+	defer s.m.mu.RUnlock()
+
+	parents := maps.Clone(s.m.parents)
+	maps.Copy(parents, s.m.view(s.tx).parents)
+	return parents, nil
+}
+
+func (s memorySets) ListAllSubsets(ctx context.Context) (map[doorman.Set][]doorman.Membership, error) {
+	s.m.mu.RLock()
+	defer s.m.mu.RUnlock()
+
+	subsets := maps.Clone(s.m.subsets)
+	maps.Copy(subsets, s.m.view(s.tx).subsets)
+	return subsets, nil
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/td0m/doorman"
+)
+
+func TestMemoryTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemory()
+
+	alice := doorman.Object("user:alice")
+	admins := doorman.Object("group:admins")
+	member := doorman.NewRole("member", []doorman.Verb{"foo"})
+	require.NoError(t, store.Roles().Add(ctx, member))
+
+	tuple := doorman.NewTuple(alice, member.ID, admins)
+
+	t.Run("Writes are only visible within tx until committed", func(t *testing.T) {
+		tx, err := store.Begin(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Tuples().WithTx(tx).Add(ctx, tuple))
+
+		parents, err := store.Tuples().WithTx(tx).ListParents(ctx, alice)
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(parents))
+
+		parents, err = store.Tuples().ListParents(ctx, alice)
+		require.NoError(t, err)
+		assert.Equal(t, 0, len(parents))
+
+		require.NoError(t, tx.Commit(ctx))
+
+		parents, err = store.Tuples().ListParents(ctx, alice)
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(parents))
+	})
+
+	t.Run("Rollback discards writes", func(t *testing.T) {
+		tx, err := store.Begin(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Tuples().WithTx(tx).Remove(ctx, tuple))
+		require.NoError(t, tx.Rollback(ctx))
+
+		parents, err := store.Tuples().ListParents(ctx, alice)
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(parents))
+	})
+
+	t.Run("Claimed changes are skipped by other txs", func(t *testing.T) {
+		require.NoError(t, store.Changes().Add(ctx, doorman.Change{ID: "1", Type: "GRANTED"}))
+
+		tx1, err := store.Begin(ctx)
+		require.NoError(t, err)
+		c, err := store.Changes().WithTx(tx1).ClaimPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "1", c.ID)
+
+		tx2, err := store.Begin(ctx)
+		require.NoError(t, err)
+		_, err = store.Changes().WithTx(tx2).ClaimPending(ctx)
+		assert.ErrorIs(t, err, ErrNoChanges)
+		require.NoError(t, tx2.Rollback(ctx))
+
+		// Released again on rollback
+		require.NoError(t, tx1.Rollback(ctx))
+		c, err = store.Changes().ClaimPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "1", c.ID)
+	})
+
+	t.Run("Set cache is only updated once the tx commits", func(t *testing.T) {
+		sets := NewSets(store.Sets())
+		parents := []doorman.Membership{{Set: doorman.NewSet(admins, "foo")}}
+
+		tx, err := store.Begin(ctx)
+		require.NoError(t, err)
+		require.NoError(t, sets.WithTx(tx).UpdateParents(ctx, alice, parents))
+		require.NoError(t, sets.Rollback(ctx, tx))
+
+		contains, _, err := sets.Contains(ctx, doorman.NewSet(admins, "foo"), alice)
+		require.NoError(t, err)
+		assert.False(t, contains)
+
+		tx, err = store.Begin(ctx)
+		require.NoError(t, err)
+		require.NoError(t, sets.WithTx(tx).UpdateParents(ctx, alice, parents))
+
+		contains, _, err = sets.Contains(ctx, doorman.NewSet(admins, "foo"), alice)
+		require.NoError(t, err)
+		assert.False(t, contains)
+
+		require.NoError(t, sets.Commit(ctx, tx))
+
+		contains, _, err = sets.Contains(ctx, doorman.NewSet(admins, "foo"), alice)
+		require.NoError(t, err)
+		assert.True(t, contains)
+	})
+}
@@ -5,26 +5,26 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/td0m/doorman"
 	"golang.org/x/exp/slices"
 )
 
 type Roles struct {
-	conn querier
+	conn   querier
+	tenant string
 }
 
-func (r Roles) WithTx(tx pgx.Tx) *Roles {
-	return &Roles{conn: tx}
+func (r Roles) WithTx(tx Tx) RoleStore {
+	return &Roles{conn: tx.(pgx.Tx), tenant: r.tenant}
 }
 
 func (r Roles) Add(ctx context.Context, role doorman.Role) error {
 	query := `
-		insert into roles(id, verbs)
-		values($1, $2)
+		insert into roles(tenant, id, verbs, includes, deny, parent)
+		values($1, $2, $3, $4, $5, $6)
 	`
 
-	if _, err := r.conn.Exec(ctx, query, role.ID, role.Verbs); err != nil {
+	if _, err := r.conn.Exec(ctx, query, r.tenant, role.ID, role.Verbs, includes(role), role.Deny, role.Parent); err != nil {
 		return err
 	}
 
@@ -33,21 +33,22 @@ func (r Roles) Add(ctx context.Context, role doorman.Role) error {
 
 func (r Roles) List(ctx context.Context) ([]doorman.Role, error) {
 	query := `
-		select id, verbs
+		select id, verbs, includes, deny, parent
 		from roles
+		where tenant = $1
 		order by id
 	`
 
 	var roles []doorman.Role
 
-	rows, err := r.conn.Query(ctx, query)
+	rows, err := r.conn.Query(ctx, query, r.tenant)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
 
 	for rows.Next() {
 		role := doorman.Role{}
-		if err := rows.Scan(&role.ID, &role.Verbs); err != nil {
+		if err := rows.Scan(&role.ID, &role.Verbs, &role.Includes, &role.Deny, &role.Parent); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		slices.Sort(role.Verbs)
@@ -59,14 +60,14 @@ func (r Roles) List(ctx context.Context) ([]doorman.Role, error) {
 
 func (r Roles) Retrieve(ctx context.Context, id string) (*doorman.Role, error) {
 	query := `
-		select verbs
+		select verbs, includes, deny, parent
 		from roles
-		where id = $1
+		where (tenant, id) = ($1, $2)
 	`
 
 	role := doorman.Role{ID: id}
 
-	err := r.conn.QueryRow(ctx, query, id).Scan(&role.Verbs)
+	err := r.conn.QueryRow(ctx, query, r.tenant, id).Scan(&role.Verbs, &role.Includes, &role.Deny, &role.Parent)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, ErrInvalidRole
@@ -79,10 +80,10 @@ func (r Roles) Retrieve(ctx context.Context, id string) (*doorman.Role, error) {
 
 func (r Roles) Remove(ctx context.Context, id string) error {
 	query := `
-		delete from roles where id=$1
+		delete from roles where (tenant, id) = ($1, $2)
 	`
 
-	if _, err := r.conn.Exec(ctx, query, id); err != nil {
+	if _, err := r.conn.Exec(ctx, query, r.tenant, id); err != nil {
 		return fmt.Errorf("exec failed: %w", err)
 	}
 
@@ -91,19 +92,27 @@ func (r Roles) Remove(ctx context.Context, id string) error {
 
 func (r Roles) Upsert(ctx context.Context, role *doorman.Role) error {
 	query := `
-		insert into roles(id, verbs)
-		values($1, $2)
-		on conflict(id) do update
-			set verbs = $2
+		insert into roles(tenant, id, verbs, includes, deny, parent)
+		values($1, $2, $3, $4, $5, $6)
+		on conflict(tenant, id) do update
+			set verbs = $3, includes = $4, deny = $5, parent = $6
 	`
 
-	if _, err := r.conn.Exec(ctx, query, role.ID, role.Verbs); err != nil {
+	if _, err := r.conn.Exec(ctx, query, r.tenant, role.ID, role.Verbs, includes(*role), role.Deny, role.Parent); err != nil {
 		return fmt.Errorf("exec failed: %w", err)
 	}
 
 	return nil
 }
 
-func NewRoles(pool *pgxpool.Pool) Roles {
-	return Roles{pool}
+// includes is never nil, as the column isn't nullable
+func includes(role doorman.Role) []string {
+	if role.Includes == nil {
+		return []string{}
+	}
+	return role.Includes
+}
+
+func NewRoles(conn querier, tenant string) Roles {
+	return Roles{conn: conn, tenant: tenant}
 }
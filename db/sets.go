@@ -2,124 +2,583 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
-	"github.com/k0kubun/pp/v3"
+	"github.com/jackc/pgx/v5"
 	"github.com/td0m/doorman"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 type Sets struct {
-	conn            querier
+	// nil within View, as the sets are already locked
+	mu *sync.RWMutex
+	// if set, updates are also written to the store
+	store SetStore
+	// if set, updates are only made to the cache once it commits, see Commit
+	tx      Tx
+	pending *pendingUpdates
+	// held while committing or loading, so that Load can't overwrite updates committed after it read the store
+	commits *sync.Mutex
+
 	subject2parents map[doorman.Object]sets
 	// recursive subsets
 	set2subset map[doorman.Set]sets
 }
 
 type sets struct {
-	m     map[doorman.Set]bool
+	// a set can be joined in more than one way, e.g. via paths with different conditions
+	m     map[doorman.Set][]doorman.Membership
 	stale bool
 }
 
 func newSets() sets {
 	return sets{
-		m:     map[doorman.Set]bool{},
+		m:     map[doorman.Set][]doorman.Membership{},
 		stale: false,
 	}
 }
 
 func (s sets) Add(set doorman.Set) {
-	s.m[set] = true
+	s.m[set] = []doorman.Membership{{Set: set}}
 }
 
-func (s sets) Remove(set doorman.Set) {
-	s.m[set] = false
+// AddMembership merges m with the other ways the set was joined in.
+// Of two with the same conditions, only the one that expires later is kept.
+func (s sets) AddMembership(m doorman.Membership) {
+	m.Conditions = normalizeConditions(m.Conditions)
+
+	existing := s.m[m.Set]
+	for i, e := range existing {
+		if !slices.Equal(e.Conditions, m.Conditions) {
+			continue
+		}
+		if e.ExpiresAt != nil && (m.ExpiresAt == nil || m.ExpiresAt.After(*e.ExpiresAt)) {
+			existing[i].ExpiresAt = m.ExpiresAt
+		}
+		return
+	}
+	s.m[m.Set] = append(existing, m)
 }
 
-func (s sets) ToList() []doorman.Set {
+// memberships lists the ways the set was joined in that haven't expired.
+func (s sets) memberships(set doorman.Set, now time.Time) []doorman.Membership {
+	var valid []doorman.Membership
+	for _, m := range s.m[set] {
+		if m.ExpiresAt == nil || now.Before(*m.ExpiresAt) {
+			valid = append(valid, m)
+		}
+	}
+	return valid
+}
+
+// ToList lists the sets with an unconditional membership.
+func (s sets) ToList(now time.Time) []doorman.Set {
 	arr := []doorman.Set{}
-	for k, v := range s.m {
-		if !v {
-			continue
+	for set := range s.m {
+		if slices.ContainsFunc(s.memberships(set, now), doorman.Membership.Unconditional) {
+			arr = append(arr, set)
 		}
-		arr = append(arr, k)
+	}
+	return arr
+}
+
+func (s sets) ToMemberships() []doorman.Membership {
+	arr := []doorman.Membership{}
+	for _, ms := range s.m {
+		arr = append(arr, ms...)
 	}
 	return arr
 }
 
 var ErrStale = errors.New("stale cache")
 
-func (s Sets) Contains(ctx context.Context, set doorman.Set, subject doorman.Object) (bool, error) {
+// View calls fn with a read only view of the sets, which stays the same until fn returns.
+func (s Sets) View(fn func(view Sets) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	view := s
+	view.mu = nil
+	return fn(view)
+}
+
+// pendingUpdates are the updates to the cache made within each tx that hasn't committed yet.
+type pendingUpdates struct {
+	mu sync.Mutex
+	m  map[Tx][]func()
+}
+
+// WithTx writes the updates to the store within tx, and holds them back from the cache until Commit.
+func (s Sets) WithTx(tx Tx) Sets {
+	if s.store != nil {
+		s.store = s.store.WithTx(tx)
+	}
+	s.tx = tx
+	return s
+}
+
+// update changes the cache, or records the change until the tx commits.
+func (s Sets) update(fn func()) {
+	if s.tx == nil {
+		defer s.lock()()
+		fn()
+		return
+	}
+
+	s.pending.mu.Lock()
+	defer s.pending.mu.Unlock()
+	s.pending.m[s.tx] = append(s.pending.m[s.tx], fn)
+}
+
+// Commit commits tx, and makes the updates made within it to the cache.
+// They are discarded if it fails, so that the cache never holds what the store doesn't.
+func (s Sets) Commit(ctx context.Context, tx Tx) error {
+	updates := s.takePending(tx)
+
+	s.commits.Lock()
+	defer s.commits.Unlock()
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	defer s.lock()()
+	for _, fn := range updates {
+		fn()
+	}
+	return nil
+}
+
+// Rollback rolls tx back, and discards the updates made within it.
+func (s Sets) Rollback(ctx context.Context, tx Tx) error {
+	s.takePending(tx)
+	return tx.Rollback(ctx)
+}
+
+func (s Sets) takePending(tx Tx) []func() {
+	s.pending.mu.Lock()
+	defer s.pending.mu.Unlock()
+
+	updates := s.pending.m[tx]
+	delete(s.pending.m, tx)
+	return updates
+}
+
+// Load replaces the cached sets with the ones persisted in the store.
+func (s Sets) Load(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+
+	s.commits.Lock()
+	defer s.commits.Unlock()
+
+	parents, err := s.store.ListAllParents(ctx)
+	if err != nil {
+		return fmt.Errorf("listAllParents failed: %w", err)
+	}
+	subsets, err := s.store.ListAllSubsets(ctx)
+	if err != nil {
+		return fmt.Errorf("listAllSubsets failed: %w", err)
+	}
+
+	defer s.lock()()
+
+	clear(s.subject2parents)
+	for subject, memberships := range parents {
+		s.subject2parents[subject] = setsFromMemberships(memberships)
+	}
+
+	clear(s.set2subset)
+	for set, memberships := range subsets {
+		subsetsWithSelf := setsFromMemberships(memberships)
+		subsetsWithSelf.Add(set)
+		s.set2subset[set] = subsetsWithSelf
+	}
+
+	return nil
+}
+
+func (s Sets) rlock() func() {
+	if s.mu == nil {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+func (s Sets) lock() func() {
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// Contains checks if the subject, or the wildcard of its type, is a member of the set.
+// If it only is under some conditions, they are returned instead: all conditions of any of them have to hold.
+//
+// Being in the denied set of set always takes precedence, however either was joined.
+// A conditional deny only applies if its conditions hold, so they are negated and added to the returned ones.
+func (s Sets) Contains(ctx context.Context, set doorman.Set, subject doorman.Object) (bool, [][]string, error) {
+	defer s.rlock()()
+
+	denied, denyConditions := s.containsSubject(set.Denied(), subject)
+	if denied {
+		return false, nil, nil
+	}
+
+	success, conditions := s.containsSubject(set, subject)
+	if len(denyConditions) == 0 {
+		return success, conditions, nil
+	}
+
+	unless := make([]string, len(denyConditions))
+	for i, c := range denyConditions {
+		unless[i] = doorman.NotAll(c)
+	}
+	if success {
+		return false, [][]string{normalizeConditions(unless)}, nil
+	}
+	for i := range conditions {
+		conditions[i] = normalizeConditions(append(conditions[i], unless...))
+	}
+	return false, conditions, nil
+}
+
+// containsSubject also checks the wildcard of the subject's type, unless it is a subject set.
+func (s Sets) containsSubject(set doorman.Set, subject doorman.Object) (bool, [][]string) {
+	success, conditions := s.contains(set, subject)
+	if success || subject.IsWildcard() || subject.IsSubjectSet() {
+		return success, conditions
+	}
+
+	success, wildcardConditions := s.contains(set, subject.Wildcard())
+	if success {
+		return true, nil
+	}
+	return false, append(conditions, wildcardConditions...)
+}
+
+func (s Sets) contains(set doorman.Set, subject doorman.Object) (bool, [][]string) {
 	parents, ok := s.subject2parents[subject]
 	if !ok {
 		return false, nil
 	}
 
-	subsets, ok := s.set2subset[set]
-	if false {
-		fmt.Println("check", set, subject)
-		pp.Println("parents", parents)
-		pp.Println("subsets", subsets)
-	}
+	now := time.Now()
+	subsets := s.subsetsOf(set, now)
+
+	return intersect(parents, subsets, now)
+}
+
+// subsetsOf also includes the subsets of the sets that set inherits from, e.g. of the folder a document is in,
+// as only the direct ones are cached. Those are the only subsets that aren't the inherits set of a group.
+func (s Sets) subsetsOf(set doorman.Set, now time.Time) sets {
+	direct, ok := s.set2subset[set]
 	if !ok {
 		self := newSets()
 		self.Add(set)
-		if intersect(parents, self) {
-			fmt.Println("cache!")
-			return true, nil
-		} else {
-			return false, nil
+		return self
+	}
+	if !slices.ContainsFunc(maps.Keys(direct.m), func(sub doorman.Set) bool { return inheritsFrom(set, sub) }) {
+		return direct
+	}
+
+	subsets := newSets()
+	subsets.Add(set)
+	visited := map[doorman.Set]bool{set: true}
+
+	var visit func(set doorman.Set, via doorman.Membership)
+	visit = func(set doorman.Set, via doorman.Membership) {
+		for sub := range s.set2subset[set].m {
+			for _, m := range s.set2subset[set].memberships(sub, now) {
+				m.Conditions = append(slices.Clone(via.Conditions), m.Conditions...)
+				if m.ExpiresAt == nil || (via.ExpiresAt != nil && via.ExpiresAt.Before(*m.ExpiresAt)) {
+					m.ExpiresAt = via.ExpiresAt
+				}
+				subsets.AddMembership(m)
+
+				if inheritsFrom(set, sub) && !visited[sub] {
+					visited[sub] = true
+					visit(sub, m)
+				}
+			}
 		}
 	}
+	visit(set, doorman.Membership{})
+
+	return subsets
+}
 
-	return intersect(parents, subsets), nil
+// inheritsFrom checks if sub is the same set of a parent, rather than the inherits set of a group.
+func inheritsFrom(set, sub doorman.Set) bool {
+	return sub.Verb == set.Verb && sub.Object != set.Object
 }
 
+// ListParents omits the sets the subject's membership has expired in, or that are conditional.
+// Includes the sets of the wildcard of the subject's type.
+// Same as in Contains, denies take precedence: sets the subject might be denied in are omitted too.
 func (s Sets) ListParents(ctx context.Context, subject doorman.Object) ([]doorman.Set, error) {
-	parents := s.subject2parents[subject]
-	return parents.ToList(), nil
+	defer s.rlock()()
+
+	now := time.Now()
+	candidates := s.subject2parents[subject].ToList(now)
+	if !subject.IsWildcard() && !subject.IsSubjectSet() {
+		candidates = append(candidates, s.subject2parents[subject.Wildcard()].ToList(now)...)
+	}
+
+	parents := []doorman.Set{}
+	for _, set := range candidates {
+		if set.IsDenied() || slices.Contains(parents, set) {
+			continue
+		}
+		if denied, conditions := s.containsSubject(set.Denied(), subject); denied || len(conditions) > 0 {
+			continue
+		}
+		parents = append(parents, set)
+	}
+	return parents, nil
 }
 
-func (s Sets) UpdateParents(ctx context.Context, subject doorman.Object, sets []doorman.Set) error {
-	s.subject2parents[subject] = setsFromList(sets)
+func (s Sets) UpdateParents(ctx context.Context, subject doorman.Object, memberships []doorman.Membership) error {
+	parents := setsFromMemberships(memberships)
+
+	if s.store != nil {
+		if err := s.store.UpdateParents(ctx, subject, parents.ToMemberships()); err != nil {
+			return fmt.Errorf("store.UpdateParents failed: %w", err)
+		}
+	}
+
+	s.update(func() { s.subject2parents[subject] = parents })
 	return nil
 }
 
 func (s Sets) InvalidateParents(ctx context.Context, subject doorman.Object) error {
-	sets := newSets()
-	s.subject2parents[subject] = sets
+	if s.store != nil {
+		if err := s.store.UpdateParents(ctx, subject, nil); err != nil {
+			return fmt.Errorf("store.UpdateParents failed: %w", err)
+		}
+	}
+
+	s.update(func() { s.subject2parents[subject] = newSets() })
 	return nil
 }
 
-func (s Sets) UpdateSubsets(ctx context.Context, set doorman.Set, subsets []doorman.Set) error {
-	// return s.modifySubset(ctx, set, subset, true)
-	subsetsWithSelf := setsFromList(subsets)
-	subsetsWithSelf.Add(set)
-	s.set2subset[set] = subsetsWithSelf
+func (s Sets) UpdateSubsets(ctx context.Context, set doorman.Set, memberships []doorman.Membership) error {
+	subsets := setsFromMemberships(memberships)
+
+	if s.store != nil {
+		if err := s.store.UpdateSubsets(ctx, set, subsets.ToMemberships()); err != nil {
+			return fmt.Errorf("store.UpdateSubsets failed: %w", err)
+		}
+	}
+
+	subsets.Add(set)
+	s.update(func() { s.set2subset[set] = subsets })
 	return nil
 }
 
-func setsFromList(ss []doorman.Set) sets {
+func setsFromMemberships(ms []doorman.Membership) sets {
 	sets := newSets()
-	for _, s := range ss {
-		sets.Add(s)
+	for _, m := range ms {
+		sets.AddMembership(m)
 	}
 	return sets
 }
 
-func intersect(a, b sets) bool {
-	for a, aconn := range a.m {
-		if aconn && b.m[a] {
-			return true
+// intersect returns the conditions a set in both has been joined under, unless one was joined unconditionally.
+func intersect(a, b sets, now time.Time) (bool, [][]string) {
+	var conditions [][]string
+	for set := range a.m {
+		for _, am := range a.memberships(set, now) {
+			for _, bm := range b.memberships(set, now) {
+				both := normalizeConditions(append(slices.Clone(am.Conditions), bm.Conditions...))
+				if len(both) == 0 {
+					return true, nil
+				}
+				if !slices.ContainsFunc(conditions, func(c []string) bool { return slices.Equal(c, both) }) {
+					conditions = append(conditions, both)
+				}
+			}
 		}
 	}
-	return false
+	return false, conditions
+}
+
+func normalizeConditions(conditions []string) []string {
+	if len(conditions) == 0 {
+		return nil
+	}
+	conditions = slices.Clone(conditions)
+	slices.Sort(conditions)
+	return slices.Compact(conditions)
 }
 
-func NewSets(q querier) Sets {
+// NewSets creates an empty cache. The store is optional.
+func NewSets(store SetStore) Sets {
 	return Sets{
-		conn:            q,
+		mu:              &sync.RWMutex{},
+		store:           store,
+		pending:         &pendingUpdates{m: map[Tx][]func(){}},
+		commits:         &sync.Mutex{},
 		subject2parents: map[doorman.Object]sets{},
 		set2subset:      map[doorman.Set]sets{},
 	}
 }
+
+// SetTables stores the sets in postgres.
+type SetTables struct {
+	conn   querier
+	tenant string
+}
+
+func (t SetTables) WithTx(tx Tx) SetStore {
+	return SetTables{conn: tx.(pgx.Tx), tenant: t.tenant}
+}
+
+func (t SetTables) UpdateParents(ctx context.Context, subject doorman.Object, parents []doorman.Membership) error {
+	if _, err := t.conn.Exec(ctx, `delete from set_parents where (tenant, subject) = ($1, $2)`, t.tenant, subject); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+
+	if len(parents) == 0 {
+		return nil
+	}
+
+	query := `
+		insert into set_parents(tenant, subject, object, verb, expires_at, conditions)
+		select $1, $2, unnest($3::text[]), unnest($4::text[]), unnest($5::timestamptz[]), unnest($6::text[])::jsonb
+		on conflict do nothing
+	`
+
+	objects, verbs, expiresAt, conditions, err := splitMemberships(parents)
+	if err != nil {
+		return err
+	}
+	if _, err := t.conn.Exec(ctx, query, t.tenant, subject, objects, verbs, expiresAt, conditions); err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+
+	return nil
+}
+
+func (t SetTables) UpdateSubsets(ctx context.Context, set doorman.Set, subsets []doorman.Membership) error {
+	query := `
+		delete from set_subsets
+		where (tenant, object, verb) = ($1, $2, $3)
+	`
+
+	if _, err := t.conn.Exec(ctx, query, t.tenant, set.Object, set.Verb); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+
+	if len(subsets) == 0 {
+		return nil
+	}
+
+	query = `
+		insert into set_subsets(tenant, object, verb, subset_object, subset_verb, expires_at, conditions)
+		select $1, $2, $3, unnest($4::text[]), unnest($5::text[]), unnest($6::timestamptz[]), unnest($7::text[])::jsonb
+		on conflict do nothing
+	`
+
+	objects, verbs, expiresAt, conditions, err := splitMemberships(subsets)
+	if err != nil {
+		return err
+	}
+	if _, err := t.conn.Exec(ctx, query, t.tenant, set.Object, set.Verb, objects, verbs, expiresAt, conditions); err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+
+	return nil
+}
+
+func (t SetTables) ListAllParents(ctx context.Context) (map[doorman.Object][]doorman.Membership, error) {
+	query := `
+		select subject, object, verb, expires_at, conditions
+		from set_parents
+		where tenant = $1
+	`
+
+	rows, err := t.conn.Query(ctx, query, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	parents := map[doorman.Object][]doorman.Membership{}
+	for rows.Next() {
+		var subject doorman.Object
+		var m doorman.Membership
+		if err := rows.Scan(&subject, &m.Set.Object, &m.Set.Verb, &m.ExpiresAt, &m.Conditions); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		parents[subject] = append(parents[subject], m)
+	}
+
+	return parents, rows.Err()
+}
+
+func (t SetTables) ListAllSubsets(ctx context.Context) (map[doorman.Set][]doorman.Membership, error) {
+	query := `
+		select object, verb, subset_object, subset_verb, expires_at, conditions
+		from set_subsets
+		where tenant = $1
+	`
+
+	rows, err := t.conn.Query(ctx, query, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	subsets := map[doorman.Set][]doorman.Membership{}
+	for rows.Next() {
+		var set doorman.Set
+		var m doorman.Membership
+		if err := rows.Scan(&set.Object, &set.Verb, &m.Set.Object, &m.Set.Verb, &m.ExpiresAt, &m.Conditions); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		subsets[set] = append(subsets[set], m)
+	}
+
+	return subsets, rows.Err()
+}
+
+// splitMemberships splits ms into columns, with the conditions as json.
+func splitMemberships(ms []doorman.Membership) ([]string, []string, []*time.Time, []string, error) {
+	objects := make([]string, len(ms))
+	verbs := make([]string, len(ms))
+	expiresAt := make([]*time.Time, len(ms))
+	conditions := make([]string, len(ms))
+	for i, m := range ms {
+		objects[i] = string(m.Set.Object)
+		verbs[i] = string(m.Set.Verb)
+		expiresAt[i] = m.ExpiresAt
+
+		bs, err := conditionsToJSON(m.Conditions)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		conditions[i] = bs
+	}
+	return objects, verbs, expiresAt, conditions, nil
+}
+
+func conditionsToJSON(conditions []string) (string, error) {
+	if conditions == nil {
+		conditions = []string{}
+	}
+	bs, err := json.Marshal(conditions)
+	if err != nil {
+		return "", fmt.Errorf("json marshaling failed: %w", err)
+	}
+	return string(bs), nil
+}
+
+func NewSetTables(conn querier, tenant string) SetTables {
+	return SetTables{conn: conn, tenant: tenant}
+}
@@ -0,0 +1,1093 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/td0m/doorman"
+	"golang.org/x/exp/slices"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const sqliteSchema = `
+	create table if not exists types(
+		tenant text not null default '',
+		id text not null,
+		nestable integer not null default 0,
+
+		primary key(tenant, id)
+	);
+
+	create table if not exists roles(
+		tenant text not null default '',
+		id text not null,
+		verbs text not null default '[]',
+		includes text not null default '[]',
+		deny integer not null default 0,
+		parent integer not null default 0,
+
+		primary key(tenant, id)
+	);
+
+	create table if not exists tuples(
+		tenant text not null default '',
+		subject text not null,
+		role text not null,
+		object text not null,
+		expires_at text,
+		condition text not null default '',
+
+		primary key(tenant, subject, role, object),
+		foreign key(tenant, role) references roles(tenant, id)
+	);
+
+	create index if not exists tuples_idx_reverse_lookup on tuples(tenant, object, role);
+	create index if not exists tuples_idx_expires_at on tuples(tenant, expires_at) where expires_at is not null;
+
+	create table if not exists changes(
+		tenant text not null default '',
+		id text primary key,
+		type text not null,
+		payload text not null,
+		status text not null default 'pending',
+		created_at text not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+		seq integer not null,
+		actor text not null default '',
+		reason text not null default '',
+		request_id text not null default ''
+	);
+
+	create unique index if not exists changes_idx_seq on changes(tenant, seq);
+	create index if not exists changes_idx_tenant on changes(tenant, status);
+	create index if not exists changes_idx_actor on changes(tenant, actor);
+
+	create table if not exists set_parents(
+		tenant text not null default '',
+		subject text not null,
+		object text not null,
+		verb text not null,
+		expires_at text,
+		conditions text not null default '[]',
+
+		primary key(tenant, subject, object, verb, conditions)
+	);
+
+	create table if not exists set_subsets(
+		tenant text not null default '',
+		object text not null,
+		verb text not null,
+		subset_object text not null,
+		subset_verb text not null,
+		expires_at text,
+		conditions text not null default '[]',
+
+		primary key(tenant, object, verb, subset_object, subset_verb, conditions)
+	);
+`
+
+// the format of strftime('%Y-%m-%dT%H:%M:%fZ'), used for created_at
+const sqliteTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// sqliteTime formats t for a nullable time column.
+func sqliteTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// sqliteNullTime scans a nullable time column.
+type sqliteNullTime struct {
+	t **time.Time
+}
+
+func (n sqliteNullTime) Scan(src any) error {
+	*n.t = nil
+	if src == nil {
+		return nil
+	}
+
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("unexpected time %T", src)
+	}
+	t, err := time.Parse(sqliteTimeFormat, s)
+	if err != nil {
+		return fmt.Errorf("time parse failed: %w", err)
+	}
+	*n.t = &t
+	return nil
+}
+
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SQLite is a Store backed by a single sqlite database file.
+//
+// sqlite only allows a single writer at a time, so every tx takes the write lock as it begins instead of
+// failing once it first writes. The tuples are locked within the process, see sqliteTuples.Lock.
+type SQLite struct {
+	db     *sql.DB
+	tenant string
+
+	mu *sync.Mutex
+	// the tuple locks of each tenant, held by the tx that locked them
+	locks map[string]chan struct{}
+}
+
+func (s *SQLite) Begin(ctx context.Context) (Tx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteTx{s: s, tx: tx}, nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLite) Tenant(id string) Store {
+	tenant := *s
+	tenant.tenant = id
+	return &tenant
+}
+
+func (s *SQLite) Tenants(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, tenantsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tenants = append(tenants, id)
+	}
+
+	return tenants, rows.Err()
+}
+
+func (s *SQLite) Changes() ChangeStore {
+	return sqliteChanges{s: s, conn: s.db}
+}
+
+func (s *SQLite) Roles() RoleStore {
+	return sqliteRoles{conn: s.db, tenant: s.tenant}
+}
+
+func (s *SQLite) Sets() SetStore {
+	return sqliteSets{conn: s.db, tenant: s.tenant}
+}
+
+func (s *SQLite) Tuples() TupleStore {
+	return sqliteTuples{conn: s.db, tenant: s.tenant}
+}
+
+func (s *SQLite) Types() TypeStore {
+	return sqliteTypes{conn: s.db, tenant: s.tenant}
+}
+
+func (s *SQLite) tenantLock(tenant string) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, ok := s.locks[tenant]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		s.locks[tenant] = lock
+	}
+	return lock
+}
+
+// OpenSQLite opens the database at path, creating it and its tables if necessary.
+func OpenSQLite(ctx context.Context, path string) (*SQLite, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open failed: %w", err)
+	}
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `select count(*) > 0 from sqlite_master where type = 'table' and name = 'types'`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("checking schema failed: %w", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, sqliteSchema); err != nil {
+		return nil, fmt.Errorf("creating schema failed: %w", err)
+	}
+
+	s := &SQLite{db: conn, mu: &sync.Mutex{}, locks: map[string]chan struct{}{}}
+
+	// Only seeded once, so that the types can still be removed
+	if !exists {
+		for _, t := range DefaultTypes {
+			if err := s.Types().Upsert(ctx, t); err != nil {
+				return nil, fmt.Errorf("seeding type %q failed: %w", t.ID, err)
+			}
+		}
+	}
+
+	return s, nil
+}
+
+type sqliteTx struct {
+	s  *SQLite
+	tx *sql.Tx
+
+	// the tuple lock held by the tx, if any
+	lock chan struct{}
+}
+
+func (tx *sqliteTx) Commit(ctx context.Context) error {
+	defer tx.release()
+
+	return tx.tx.Commit()
+}
+
+func (tx *sqliteTx) Rollback(ctx context.Context) error {
+	defer tx.release()
+
+	return tx.tx.Rollback()
+}
+
+func (tx *sqliteTx) release() {
+	if tx.lock != nil {
+		<-tx.lock
+		tx.lock = nil
+	}
+}
+
+type sqliteTuples struct {
+	conn   sqlQuerier
+	tx     *sqliteTx
+	tenant string
+}
+
+func (t sqliteTuples) WithTx(tx Tx) TupleStore {
+	sqlTx := tx.(*sqliteTx)
+	return sqliteTuples{conn: sqlTx.tx, tx: sqlTx, tenant: t.tenant}
+}
+
+func (t sqliteTuples) Lock(ctx context.Context) error {
+	if t.tx == nil || t.tx.lock != nil {
+		return nil
+	}
+
+	lock := t.tx.s.tenantLock(t.tenant)
+	select {
+	case lock <- struct{}{}:
+		t.tx.lock = lock
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("locking tuples failed: %w", ctx.Err())
+	}
+}
+
+func (t sqliteTuples) Add(ctx context.Context, tuple doorman.Tuple) error {
+	query := `
+		insert into tuples(tenant, subject, role, object, expires_at, condition)
+		values(?, ?, ?, ?, ?, ?)
+	`
+
+	if _, err := t.conn.ExecContext(ctx, query, t.tenant, tuple.Subject, tuple.Role, tuple.Object, sqliteTime(tuple.ExpiresAt), tuple.Condition); err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) {
+			switch sqliteErr.Code() {
+			case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+				return doorman.ErrTupleExists
+			case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+				return ErrInvalidRole
+			}
+		}
+		return err
+	}
+
+	connected, err := sqliteListConnectedTiny(ctx, t.conn, t.tenant, tuple.Object, tuple.Role)
+	if err != nil {
+		return fmt.Errorf("listConnected failed: %w", err)
+	}
+	for _, o := range connected {
+		if o == tuple.Subject {
+			return ErrCycle
+		}
+	}
+
+	return nil
+}
+
+func (t sqliteTuples) List(ctx context.Context) ([]doorman.Tuple, error) {
+	query := `
+		select subject, role, object, expires_at, condition
+		from tuples
+		where tenant = ?
+		order by subject, role, object
+	`
+
+	rows, err := t.conn.QueryContext(ctx, query, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var tuples []doorman.Tuple
+	for rows.Next() {
+		t := doorman.Tuple{}
+		if err := rows.Scan(&t.Subject, &t.Role, &t.Object, sqliteNullTime{&t.ExpiresAt}, &t.Condition); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tuples = append(tuples, t)
+	}
+	return tuples, rows.Err()
+}
+
+func (t sqliteTuples) ListParents(ctx context.Context, subject doorman.Object) ([]doorman.Tuple, error) {
+	query := `
+		select role, object, expires_at, condition
+		from tuples
+		where tenant = ? and subject = ?
+	`
+
+	rows, err := t.conn.QueryContext(ctx, query, t.tenant, subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tuples []doorman.Tuple
+	for rows.Next() {
+		tuple := doorman.Tuple{Subject: subject}
+		if err := rows.Scan(&tuple.Role, &tuple.Object, sqliteNullTime{&tuple.ExpiresAt}, &tuple.Condition); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	return tuples, rows.Err()
+}
+
+func (t sqliteTuples) ListTuplesBetween(ctx context.Context, subject, object doorman.Object) ([]doorman.Tuple, error) {
+	query := `
+		select role, expires_at, condition
+		from tuples
+		where tenant = ? and subject = ? and object = ?
+	`
+
+	rows, err := t.conn.QueryContext(ctx, query, t.tenant, subject, object)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tuples []doorman.Tuple
+	for rows.Next() {
+		tuple := doorman.Tuple{Subject: subject, Object: object}
+		if err := rows.Scan(&tuple.Role, sqliteNullTime{&tuple.ExpiresAt}, &tuple.Condition); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	return tuples, rows.Err()
+}
+
+func (t sqliteTuples) Remove(ctx context.Context, tuple doorman.Tuple) error {
+	query := `
+		delete from tuples
+		where tenant = ? and subject = ? and role = ? and object = ?
+	`
+
+	res, err := t.conn.ExecContext(ctx, query, t.tenant, tuple.Subject, tuple.Role, tuple.Object)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if affected == 0 {
+		return doorman.ErrTupleNotFound
+	}
+
+	return nil
+}
+
+func (t sqliteTuples) HasTuplesForType(ctx context.Context, typ string) (bool, error) {
+	query := `
+		select exists(
+			select 1
+			from tuples
+			where tenant = ?2 and (substr(subject, 1, length(?1)) = ?1 or substr(object, 1, length(?1)) = ?1)
+		)
+	`
+
+	var exists bool
+	if err := t.conn.QueryRowContext(ctx, query, typ+":", t.tenant).Scan(&exists); err != nil {
+		return false, fmt.Errorf("query failed: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (t sqliteTuples) ListTuplesForRole(ctx context.Context, role string) ([]doorman.Tuple, error) {
+	query := `
+		select subject, object, expires_at, condition
+		from tuples
+		where tenant = ? and role = ?
+	`
+
+	rows, err := t.conn.QueryContext(ctx, query, t.tenant, role)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var tuples []doorman.Tuple
+	for rows.Next() {
+		t := doorman.Tuple{Role: role}
+		if err := rows.Scan(&t.Subject, &t.Object, sqliteNullTime{&t.ExpiresAt}, &t.Condition); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tuples = append(tuples, t)
+	}
+	return tuples, rows.Err()
+}
+
+func (t sqliteTuples) ListExpiredTuples(ctx context.Context, before time.Time) ([]doorman.Tuple, error) {
+	query := `
+		select subject, role, object, expires_at, condition
+		from tuples
+		where tenant = ? and expires_at < ?
+	`
+
+	rows, err := t.conn.QueryContext(ctx, query, t.tenant, sqliteTime(&before))
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	tuples := []doorman.Tuple{}
+	for rows.Next() {
+		t := doorman.Tuple{}
+		if err := rows.Scan(&t.Subject, &t.Role, &t.Object, sqliteNullTime{&t.ExpiresAt}, &t.Condition); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tuples = append(tuples, t)
+	}
+	return tuples, rows.Err()
+}
+
+func (t sqliteTuples) ListConnected(ctx context.Context, subject doorman.Object, inverted bool) ([]doorman.Path, error) {
+	// Same as the postgres query, except that paths are json arrays instead of text[]
+	query := `
+		with recursive connections(object, subject_set, via, depth) as (
+			select
+				object,
+				case when substr(role, 1, instr(role, ':')) = substr(object, 1, instr(object, ':')) then object || '#' || substr(role, instr(role, ':') + 1) end,
+				json_array(role, object, coalesce(expires_at, ''), condition),
+				1
+			from tuples
+			where tenant = ?2 and subject = ?1
+
+			union
+
+			select
+				next.object,
+				case when substr(next.role, 1, instr(next.role, ':')) = substr(next.object, 1, instr(next.object, ':')) then next.object || '#' || substr(next.role, instr(next.role, ':') + 1) end,
+				json_insert(
+					case when next.subject = prev.subject_set then json_insert(prev.via, '$[#]', '', '$[#]', next.subject, '$[#]', '', '$[#]', '') else prev.via end,
+					'$[#]', next.role, '$[#]', next.object, '$[#]', coalesce(next.expires_at, ''), '$[#]', next.condition
+				),
+				prev.depth + 1
+			from tuples next
+			inner join
+				connections prev on prev.object = next.subject or prev.subject_set = next.subject
+			where next.tenant = ?2 and next.object != ?1
+		) select via from connections order by depth, via
+	`
+
+	if inverted {
+		query = sqliteInvertedConnections + ` select via from inverted_connections order by depth, via`
+	}
+
+	rows, err := t.conn.QueryContext(ctx, query, subject, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("exec failed: %w", err)
+	}
+
+	return scanSQLitePaths(rows)
+}
+
+func (t sqliteTuples) ListSubjectPaths(ctx context.Context, object, after doorman.Object, limit int) ([]doorman.Path, error) {
+	query := sqliteInvertedConnections + `
+		select via from inverted_connections
+		where subject like '%:*' or subject in (
+			select distinct subject from inverted_connections
+			where subject > ?3
+			order by subject
+			limit ?4
+		)
+		order by subject, depth, via
+	`
+
+	rows, err := t.conn.QueryContext(ctx, query, object, t.tenant, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("exec failed: %w", err)
+	}
+
+	return scanSQLitePaths(rows)
+}
+
+// sqliteInvertedConnections is the recursive part of the inverted ListConnected query, with the subject as ?1 and the tenant as ?2.
+const sqliteInvertedConnections = `
+		with recursive inverted_connections(subject, set_object, set_role, via, depth) as (
+			select
+				subject,
+				case when instr(subject, '#') > 0 then substr(subject, 1, instr(subject, '#') - 1) end,
+				substr(subject, 1, instr(subject, ':')) || substr(subject, instr(subject, '#') + 1),
+				json_array(role, subject, coalesce(expires_at, ''), condition),
+				1
+			from tuples
+			where tenant = ?2 and object = ?1
+
+			union
+
+			select
+				next.subject,
+				case when instr(next.subject, '#') > 0 then substr(next.subject, 1, instr(next.subject, '#') - 1) end,
+				substr(next.subject, 1, instr(next.subject, ':')) || substr(next.subject, instr(next.subject, '#') + 1),
+				json_insert(
+					case when next.object = prev.set_object then json_insert(prev.via, '$[#]', '', '$[#]', next.object, '$[#]', '', '$[#]', '') else prev.via end,
+					'$[#]', next.role, '$[#]', next.subject, '$[#]', coalesce(next.expires_at, ''), '$[#]', next.condition
+				),
+				prev.depth + 1
+			from tuples next
+			inner join
+				inverted_connections prev on prev.subject = next.object or (prev.set_object = next.object and prev.set_role = next.role)
+			where next.tenant = ?2 and next.subject != ?1
+		)
+`
+
+func scanSQLitePaths(rows *sql.Rows) ([]doorman.Path, error) {
+	defer rows.Close()
+
+	var paths []doorman.Path
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("row scan failed: %w", err)
+		}
+		var via []string
+		if err := json.Unmarshal([]byte(raw), &via); err != nil {
+			return nil, fmt.Errorf("json unmarshal failed: %w", err)
+		}
+		path, err := pathFromVia(via)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, rows.Err()
+}
+
+func sqliteListConnectedTiny(ctx context.Context, conn sqlQuerier, tenant string, subject doorman.Object, role string) ([]doorman.Object, error) {
+	query := `
+		with recursive connections(object, subject_set) as (
+			select
+				object,
+				case when substr(role, 1, instr(role, ':')) = substr(object, 1, instr(object, ':')) then object || '#' || substr(role, instr(role, ':') + 1) end
+			from tuples
+			where tenant = ?3 and subject in (?1, ?2)
+
+			union
+
+			select
+				next.object,
+				case when substr(next.role, 1, instr(next.role, ':')) = substr(next.object, 1, instr(next.object, ':')) then next.object || '#' || substr(next.role, instr(next.role, ':') + 1) end
+			from tuples next
+			inner join
+				connections prev on prev.object = next.subject or prev.subject_set = next.subject
+			where next.tenant = ?3 and next.object != ?1
+		)
+		select object from connections
+		union
+		select subject_set from connections where subject_set is not null
+	`
+
+	set, _ := subject.SubjectSet(role)
+	rows, err := conn.QueryContext(ctx, query, subject, set, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []doorman.Object
+	if set != "" {
+		objects = append(objects, set)
+	}
+	for rows.Next() {
+		o := doorman.Object("")
+		if err := rows.Scan(&o); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		objects = append(objects, o)
+	}
+
+	return objects, rows.Err()
+}
+
+type sqliteRoles struct {
+	conn   sqlQuerier
+	tenant string
+}
+
+func (r sqliteRoles) WithTx(tx Tx) RoleStore {
+	return sqliteRoles{conn: tx.(*sqliteTx).tx, tenant: r.tenant}
+}
+
+func (r sqliteRoles) Add(ctx context.Context, role doorman.Role) error {
+	query := `
+		insert into roles(tenant, id, verbs, includes, deny, parent)
+		values(?, ?, ?, ?, ?, ?)
+	`
+
+	verbs, err := json.Marshal(role.Verbs)
+	if err != nil {
+		return fmt.Errorf("json marshaling failed: %w", err)
+	}
+	roleIncludes, err := json.Marshal(includes(role))
+	if err != nil {
+		return fmt.Errorf("json marshaling failed: %w", err)
+	}
+
+	if _, err := r.conn.ExecContext(ctx, query, r.tenant, role.ID, string(verbs), string(roleIncludes), role.Deny, role.Parent); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r sqliteRoles) List(ctx context.Context) ([]doorman.Role, error) {
+	query := `
+		select id, verbs, includes, deny, parent
+		from roles
+		where tenant = ?
+		order by id
+	`
+
+	var roles []doorman.Role
+
+	rows, err := r.conn.QueryContext(ctx, query, r.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		role := doorman.Role{}
+		var verbs, roleIncludes string
+		if err := rows.Scan(&role.ID, &verbs, &roleIncludes, &role.Deny, &role.Parent); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if err := json.Unmarshal([]byte(verbs), &role.Verbs); err != nil {
+			return nil, fmt.Errorf("json unmarshal failed: %w", err)
+		}
+		if err := json.Unmarshal([]byte(roleIncludes), &role.Includes); err != nil {
+			return nil, fmt.Errorf("json unmarshal failed: %w", err)
+		}
+		slices.Sort(role.Verbs)
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r sqliteRoles) Retrieve(ctx context.Context, id string) (*doorman.Role, error) {
+	query := `
+		select verbs, includes, deny, parent
+		from roles
+		where tenant = ? and id = ?
+	`
+
+	role := doorman.Role{ID: id}
+
+	var verbs, roleIncludes string
+	err := r.conn.QueryRowContext(ctx, query, r.tenant, id).Scan(&verbs, &roleIncludes, &role.Deny, &role.Parent)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidRole
+		}
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(verbs), &role.Verbs); err != nil {
+		return nil, fmt.Errorf("json unmarshal failed: %w", err)
+	}
+	if err := json.Unmarshal([]byte(roleIncludes), &role.Includes); err != nil {
+		return nil, fmt.Errorf("json unmarshal failed: %w", err)
+	}
+
+	return &role, nil
+}
+
+func (r sqliteRoles) Remove(ctx context.Context, id string) error {
+	query := `
+		delete from roles where tenant = ? and id = ?
+	`
+
+	if _, err := r.conn.ExecContext(ctx, query, r.tenant, id); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r sqliteRoles) Upsert(ctx context.Context, role *doorman.Role) error {
+	query := `
+		insert into roles(tenant, id, verbs, includes, deny, parent)
+		values(?1, ?2, ?3, ?4, ?5, ?6)
+		on conflict(tenant, id) do update
+			set verbs = ?3, includes = ?4, deny = ?5, parent = ?6
+	`
+
+	verbs, err := json.Marshal(role.Verbs)
+	if err != nil {
+		return fmt.Errorf("json marshaling failed: %w", err)
+	}
+	roleIncludes, err := json.Marshal(includes(*role))
+	if err != nil {
+		return fmt.Errorf("json marshaling failed: %w", err)
+	}
+
+	if _, err := r.conn.ExecContext(ctx, query, r.tenant, role.ID, string(verbs), string(roleIncludes), role.Deny, role.Parent); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
+	}
+
+	return nil
+}
+
+type sqliteSets struct {
+	conn   sqlQuerier
+	tenant string
+}
+
+func (t sqliteSets) WithTx(tx Tx) SetStore {
+	return sqliteSets{conn: tx.(*sqliteTx).tx, tenant: t.tenant}
+}
+
+func (t sqliteSets) UpdateParents(ctx context.Context, subject doorman.Object, parents []doorman.Membership) error {
+	if _, err := t.conn.ExecContext(ctx, `delete from set_parents where tenant = ? and subject = ?`, t.tenant, subject); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+
+	query := `
+		insert or ignore into set_parents(tenant, subject, object, verb, expires_at, conditions)
+		values(?, ?, ?, ?, ?, ?)
+	`
+
+	for _, m := range parents {
+		conditions, err := conditionsToJSON(m.Conditions)
+		if err != nil {
+			return err
+		}
+		if _, err := t.conn.ExecContext(ctx, query, t.tenant, subject, m.Set.Object, m.Set.Verb, sqliteTime(m.ExpiresAt), conditions); err != nil {
+			return fmt.Errorf("insert failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (t sqliteSets) UpdateSubsets(ctx context.Context, set doorman.Set, subsets []doorman.Membership) error {
+	if _, err := t.conn.ExecContext(ctx, `delete from set_subsets where tenant = ? and object = ? and verb = ?`, t.tenant, set.Object, set.Verb); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+
+	query := `
+		insert or ignore into set_subsets(tenant, object, verb, subset_object, subset_verb, expires_at, conditions)
+		values(?, ?, ?, ?, ?, ?, ?)
+	`
+
+	for _, m := range subsets {
+		conditions, err := conditionsToJSON(m.Conditions)
+		if err != nil {
+			return err
+		}
+		if _, err := t.conn.ExecContext(ctx, query, t.tenant, set.Object, set.Verb, m.Set.Object, m.Set.Verb, sqliteTime(m.ExpiresAt), conditions); err != nil {
+			return fmt.Errorf("insert failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (t sqliteSets) ListAllParents(ctx context.Context) (map[doorman.Object][]doorman.Membership, error) {
+	rows, err := t.conn.QueryContext(ctx, `select subject, object, verb, expires_at, conditions from set_parents where tenant = ?`, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	parents := map[doorman.Object][]doorman.Membership{}
+	for rows.Next() {
+		var subject doorman.Object
+		var m doorman.Membership
+		var conditions string
+		if err := rows.Scan(&subject, &m.Set.Object, &m.Set.Verb, sqliteNullTime{&m.ExpiresAt}, &conditions); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if err := json.Unmarshal([]byte(conditions), &m.Conditions); err != nil {
+			return nil, fmt.Errorf("json unmarshal failed: %w", err)
+		}
+		parents[subject] = append(parents[subject], m)
+	}
+
+	return parents, rows.Err()
+}
+
+func (t sqliteSets) ListAllSubsets(ctx context.Context) (map[doorman.Set][]doorman.Membership, error) {
+	rows, err := t.conn.QueryContext(ctx, `select object, verb, subset_object, subset_verb, expires_at, conditions from set_subsets where tenant = ?`, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	subsets := map[doorman.Set][]doorman.Membership{}
+	for rows.Next() {
+		var set doorman.Set
+		var m doorman.Membership
+		var conditions string
+		if err := rows.Scan(&set.Object, &set.Verb, &m.Set.Object, &m.Set.Verb, sqliteNullTime{&m.ExpiresAt}, &conditions); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if err := json.Unmarshal([]byte(conditions), &m.Conditions); err != nil {
+			return nil, fmt.Errorf("json unmarshal failed: %w", err)
+		}
+		subsets[set] = append(subsets[set], m)
+	}
+
+	return subsets, rows.Err()
+}
+
+type sqliteTypes struct {
+	conn   sqlQuerier
+	tenant string
+}
+
+func (t sqliteTypes) WithTx(tx Tx) TypeStore {
+	return sqliteTypes{conn: tx.(*sqliteTx).tx, tenant: t.tenant}
+}
+
+func (t sqliteTypes) List(ctx context.Context) ([]doorman.Type, error) {
+	rows, err := t.conn.QueryContext(ctx, `select id, nestable from types where tenant = ? order by id`, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	types := []doorman.Type{}
+	for rows.Next() {
+		typ := doorman.Type{}
+		if err := rows.Scan(&typ.ID, &typ.Nestable); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		types = append(types, typ)
+	}
+
+	return types, rows.Err()
+}
+
+func (t sqliteTypes) Retrieve(ctx context.Context, id string) (*doorman.Type, error) {
+	typ := doorman.Type{}
+	if err := t.conn.QueryRowContext(ctx, `select id, nestable from types where tenant = ? and id = ?`, t.tenant, id).Scan(&typ.ID, &typ.Nestable); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidType
+		}
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	return &typ, nil
+}
+
+func (t sqliteTypes) Remove(ctx context.Context, id string) error {
+	if _, err := t.conn.ExecContext(ctx, `delete from types where tenant = ? and id = ?`, t.tenant, id); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
+	}
+
+	return nil
+}
+
+func (t sqliteTypes) Upsert(ctx context.Context, typ doorman.Type) error {
+	query := `
+		insert into types(tenant, id, nestable)
+		values(?1, ?2, ?3)
+		on conflict(tenant, id) do update
+			set nestable = ?3
+	`
+
+	if _, err := t.conn.ExecContext(ctx, query, t.tenant, typ.ID, typ.Nestable); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
+	}
+
+	return nil
+}
+
+type sqliteChanges struct {
+	s    *SQLite
+	conn sqlQuerier
+}
+
+func (c sqliteChanges) WithTx(tx Tx) ChangeStore {
+	return sqliteChanges{s: c.s, conn: tx.(*sqliteTx).tx}
+}
+
+// Add assigns the change the next seq of the tenant, which follows the commit order as sqlite has a single writer.
+func (cs sqliteChanges) Add(ctx context.Context, c doorman.Change) error {
+	query := `
+		insert into changes(tenant, seq, id, type, payload, actor, reason, request_id)
+		select ?1, coalesce(max(seq), 0) + 1, ?2, ?3, ?4, ?5, ?6, ?7
+		from changes
+		where tenant = ?1
+	`
+
+	if _, err := cs.conn.ExecContext(ctx, query, cs.s.tenant, c.ID, c.Type, string(c.Payload), c.Actor, c.Reason, c.RequestID); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
+	}
+	return nil
+}
+
+func (cs sqliteChanges) List(ctx context.Context, f ChangeFilter) ([]doorman.Change, error) {
+	where, params := filterBy(cs.s.tenant, &f)
+
+	// created_at is stored as text, which only compares correctly in the same format
+	for i, param := range params {
+		if t, ok := param.(*time.Time); ok {
+			params[i] = t.UTC().Format(sqliteTimeFormat)
+		}
+	}
+
+	query := `
+		select id, type, payload, status, created_at, seq, actor, reason, request_id
+		from changes
+	` + where + `
+		order by seq
+	` + f.limit()
+
+	rows, err := cs.conn.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []doorman.Change{}
+	for rows.Next() {
+		change, err := scanSQLiteChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func (cs sqliteChanges) LastSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := cs.conn.QueryRowContext(ctx, `select coalesce(max(seq), 0) from changes where tenant = ?`, cs.s.tenant).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("query failed: %w", err)
+	}
+	return seq, nil
+}
+
+func (cs sqliteChanges) Retrieve(ctx context.Context, id string) (*doorman.Change, error) {
+	query := `
+		select id, type, payload, status, created_at, seq, actor, reason, request_id
+		from changes
+		where tenant = ? and id = ?
+	`
+
+	c, err := scanSQLiteChange(cs.conn.QueryRowContext(ctx, query, cs.s.tenant, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidChange
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// ClaimPending picks the change outside of the tx, so that the tx does not hold the write lock
+// while the change is being processed.
+// ClaimPending marks a random pending change as processed, which is undone if the tx is rolled back.
+// No other tx can claim it meanwhile, as the tx holds the write lock.
+func (cs sqliteChanges) ClaimPending(ctx context.Context) (doorman.Change, error) {
+	query := `
+		update changes
+		set status = 'processed'
+		where id = (
+			select id
+			from changes
+			where tenant = ? and status = 'pending'
+			order by random()
+			limit 1
+		)
+		returning id, type, payload, status, created_at, seq, actor, reason, request_id
+	`
+
+	c, err := scanSQLiteChange(cs.conn.QueryRowContext(ctx, query, cs.s.tenant))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, ErrNoChanges
+	}
+	return c, err
+}
+
+func (cs sqliteChanges) SetStatusOfAll(ctx context.Context, status string) error {
+	query := `
+		update changes
+		set status = ?
+		where tenant = ?
+	`
+
+	if _, err := cs.conn.ExecContext(ctx, query, status, cs.s.tenant); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSQLiteChange(row scanner) (doorman.Change, error) {
+	var change doorman.Change
+	var payload, createdAt string
+	if err := row.Scan(&change.ID, &change.Type, &payload, &change.Status, &createdAt, &change.Seq, &change.Actor, &change.Reason, &change.RequestID); err != nil {
+		return change, fmt.Errorf("scan failed: %w", err)
+	}
+	change.Payload = json.RawMessage(payload)
+
+	var err error
+	change.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return change, fmt.Errorf("parsing created_at failed: %w", err)
+	}
+
+	return change, nil
+}
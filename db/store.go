@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/td0m/doorman"
+)
+
+var ErrNoChanges = errors.New("no pending changes")
+var ErrInvalidChange = errors.New("this change does not exist")
+var ErrInvalidType = errors.New("this type does not exist")
+
+// DefaultTypes are registered in the default tenant of a new store, as groups were nestable before any type could be.
+var DefaultTypes = []doorman.Type{{ID: "group", Nestable: true}}
+
+// Tx is a transaction spanning all the stores of a single backend.
+// Stores bound to a tx via WithTx only see its writes once it is committed.
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+type Store interface {
+	Begin(ctx context.Context) (Tx, error)
+
+	// Tenant returns the stores of a single tenant, which don't see the types, roles, tuples, changes and sets of the others.
+	// The stores of a new backend are the ones of the default tenant, whose id is empty.
+	Tenant(id string) Store
+	// Tenants lists the ids of the tenants with anything stored, ordered by id.
+	Tenants(ctx context.Context) ([]string, error)
+
+	Changes() ChangeStore
+	Roles() RoleStore
+	Sets() SetStore
+	Tuples() TupleStore
+	Types() TypeStore
+}
+
+type TupleStore interface {
+	WithTx(tx Tx) TupleStore
+
+	// Lock blocks other transactions from locking the tuples until tx is finished.
+	Lock(ctx context.Context) error
+
+	// Add fails with ErrCycle if the new tuple connects the subject to itself.
+	Add(ctx context.Context, tuple doorman.Tuple) error
+	Remove(ctx context.Context, tuple doorman.Tuple) error
+
+	// List lists every tuple, ordered by subject, role and object.
+	List(ctx context.Context) ([]doorman.Tuple, error)
+	ListParents(ctx context.Context, subject doorman.Object) ([]doorman.Tuple, error)
+	ListTuplesBetween(ctx context.Context, subject, object doorman.Object) ([]doorman.Tuple, error)
+	ListTuplesForRole(ctx context.Context, role string) ([]doorman.Tuple, error)
+	// HasTuplesForType reports whether any tuple has a subject or object of the type.
+	HasTuplesForType(ctx context.Context, typ string) (bool, error)
+	// ListExpiredTuples lists the tuples that expire before the given time.
+	ListExpiredTuples(ctx context.Context, before time.Time) ([]doorman.Tuple, error)
+
+	// ListConnected returns every path starting at subject, shortest first.
+	// If inverted, the tuples are followed from object to subject instead.
+	ListConnected(ctx context.Context, subject doorman.Object, inverted bool) ([]doorman.Path, error)
+	// ListSubjectPaths is an inverted ListConnected that only returns the paths to the first limit subjects after
+	// the given one, ordered by subject, along with the paths to wildcards as they can deny the other subjects.
+	ListSubjectPaths(ctx context.Context, object, after doorman.Object, limit int) ([]doorman.Path, error)
+}
+
+// SetStore persists the sets cached by Sets, so that they can be loaded instead of rebuilt.
+type SetStore interface {
+	WithTx(tx Tx) SetStore
+
+	// UpdateParents replaces the sets the subject is directly a member of.
+	UpdateParents(ctx context.Context, subject doorman.Object, parents []doorman.Membership) error
+	// UpdateSubsets replaces the subsets of set.
+	UpdateSubsets(ctx context.Context, set doorman.Set, subsets []doorman.Membership) error
+
+	ListAllParents(ctx context.Context) (map[doorman.Object][]doorman.Membership, error)
+	ListAllSubsets(ctx context.Context) (map[doorman.Set][]doorman.Membership, error)
+}
+
+type RoleStore interface {
+	WithTx(tx Tx) RoleStore
+
+	Add(ctx context.Context, role doorman.Role) error
+	List(ctx context.Context) ([]doorman.Role, error)
+	Retrieve(ctx context.Context, id string) (*doorman.Role, error)
+	Remove(ctx context.Context, id string) error
+	Upsert(ctx context.Context, role *doorman.Role) error
+}
+
+type TypeStore interface {
+	WithTx(tx Tx) TypeStore
+
+	List(ctx context.Context) ([]doorman.Type, error)
+	Retrieve(ctx context.Context, id string) (*doorman.Type, error)
+	Remove(ctx context.Context, id string) error
+	Upsert(ctx context.Context, t doorman.Type) error
+}
+
+type ChangeStore interface {
+	WithTx(tx Tx) ChangeStore
+
+	Add(ctx context.Context, c doorman.Change) error
+	// List lists the changes in the order they were committed, see doorman.Change.Seq.
+	List(ctx context.Context, f ChangeFilter) ([]doorman.Change, error)
+	// LastSeq returns the seq of the last change committed, or 0 if there are none.
+	LastSeq(ctx context.Context) (int64, error)
+	// Retrieve fails with ErrInvalidChange if there is no change with the id.
+	Retrieve(ctx context.Context, id string) (*doorman.Change, error)
+
+	// ClaimPending marks a single pending change as processed and returns it.
+	// Other transactions will not claim the same change until tx is rolled back.
+	// Returns ErrNoChanges if there is nothing left to claim.
+	ClaimPending(ctx context.Context) (doorman.Change, error)
+	SetStatusOfAll(ctx context.Context, status string) error
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -14,29 +15,30 @@ var ErrInvalidRole = errors.New("this role does not exist")
 var ErrCycle = errors.New("cycle detected")
 
 type Tuples struct {
-	conn querier
+	conn   querier
+	tenant string
 }
 
-func (t Tuples) WithTx(tx pgx.Tx) *Tuples {
-	return &Tuples{conn: tx}
+func (t Tuples) WithTx(tx Tx) TupleStore {
+	return &Tuples{conn: tx.(pgx.Tx), tenant: t.tenant}
 }
 
+// Lock takes an advisory lock of the tenant until tx is finished, so that the other tenants aren't blocked.
+// Without locking we can get some issues with concurrent writes.
 func (t Tuples) Lock(ctx context.Context) error {
-	// Without locking we can get some issues with concurrent writes
-	// Comment out for proof / check what will break
-	if _, err := t.conn.Exec(ctx, `lock table tuples in access exclusive mode`); err != nil {
-		return fmt.Errorf("locking table tuples failed: %w", err)
+	if _, err := t.conn.Exec(ctx, `select pg_advisory_xact_lock(hashtext('doorman.tuples'), hashtext($1))`, t.tenant); err != nil {
+		return fmt.Errorf("locking the tuples of tenant %q failed: %w", t.tenant, err)
 	}
 	return nil
 }
 
 func (t Tuples) Add(ctx context.Context, tuple doorman.Tuple) error {
 	query := `
-		insert into tuples(subject, role, object)
-		values($1, $2, $3)
+		insert into tuples(tenant, subject, role, object, expires_at, condition)
+		values($1, $2, $3, $4, $5, $6)
 	`
 
-	if _, err := t.conn.Exec(ctx, query, tuple.Subject, tuple.Role, tuple.Object); err != nil {
+	if _, err := t.conn.Exec(ctx, query, t.tenant, tuple.Subject, tuple.Role, tuple.Object, tuple.ExpiresAt, tuple.Condition); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			if pgErr.ConstraintName == "tuples_pkey" && pgErr.Code == "23505" {
@@ -49,7 +51,7 @@ func (t Tuples) Add(ctx context.Context, tuple doorman.Tuple) error {
 		return err
 	}
 
-	connected, err := listConnectedTiny(ctx, t.conn, tuple.Object)
+	connected, err := listConnectedTiny(ctx, t.conn, t.tenant, tuple.Object, tuple.Role)
 	if err != nil {
 		return fmt.Errorf("listConnected failed: %w", err)
 	}
@@ -62,14 +64,38 @@ func (t Tuples) Add(ctx context.Context, tuple doorman.Tuple) error {
 	return nil
 }
 
+func (t Tuples) List(ctx context.Context) ([]doorman.Tuple, error) {
+	query := `
+		select subject, role, object, expires_at, condition
+		from tuples
+		where tenant = $1
+		order by subject, role, object
+	`
+
+	rows, err := t.conn.Query(ctx, query, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	var tuples []doorman.Tuple
+	for rows.Next() {
+		t := doorman.Tuple{}
+		if err := rows.Scan(&t.Subject, &t.Role, &t.Object, &t.ExpiresAt, &t.Condition); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tuples = append(tuples, t)
+	}
+	return tuples, rows.Err()
+}
+
 func (t Tuples) ListParents(ctx context.Context, subject doorman.Object) ([]doorman.Tuple, error) {
 	query := `
-		select role, object
+		select role, object, expires_at, condition
 		from tuples
-		where (subject) = ($1)
+		where (tenant, subject) = ($1, $2)
 	`
 
-	rows, err := t.conn.Query(ctx, query, subject)
+	rows, err := t.conn.Query(ctx, query, t.tenant, subject)
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +103,7 @@ func (t Tuples) ListParents(ctx context.Context, subject doorman.Object) ([]door
 	var tuples []doorman.Tuple
 	for rows.Next() {
 		tuple := doorman.Tuple{Subject: subject}
-		if err := rows.Scan(&tuple.Role, &tuple.Object); err != nil {
+		if err := rows.Scan(&tuple.Role, &tuple.Object, &tuple.ExpiresAt, &tuple.Condition); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		tuples = append(tuples, tuple)
@@ -88,12 +114,12 @@ func (t Tuples) ListParents(ctx context.Context, subject doorman.Object) ([]door
 
 func (t Tuples) ListTuplesBetween(ctx context.Context, subject, object doorman.Object) ([]doorman.Tuple, error) {
 	query := `
-		select role
+		select role, expires_at, condition
 		from tuples
-		where (subject, object) = ($1, $2)
+		where (tenant, subject, object) = ($1, $2, $3)
 	`
 
-	rows, err := t.conn.Query(ctx, query, subject, object)
+	rows, err := t.conn.Query(ctx, query, t.tenant, subject, object)
 	if err != nil {
 		return nil, err
 	}
@@ -101,7 +127,7 @@ func (t Tuples) ListTuplesBetween(ctx context.Context, subject, object doorman.O
 	var tuples []doorman.Tuple
 	for rows.Next() {
 		tuple := doorman.Tuple{Subject: subject, Object: object}
-		if err := rows.Scan(&tuple.Role); err != nil {
+		if err := rows.Scan(&tuple.Role, &tuple.ExpiresAt, &tuple.Condition); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		tuples = append(tuples, tuple)
@@ -113,10 +139,10 @@ func (t Tuples) ListTuplesBetween(ctx context.Context, subject, object doorman.O
 func (t Tuples) Remove(ctx context.Context, tuple doorman.Tuple) error {
 	query := `
 		delete from tuples
-		where (subject, role, object) = ($1, $2, $3)
+		where (tenant, subject, role, object) = ($1, $2, $3, $4)
 	`
 
-	tag, err := t.conn.Exec(ctx, query, tuple.Subject, tuple.Role, tuple.Object)
+	tag, err := t.conn.Exec(ctx, query, t.tenant, tuple.Subject, tuple.Role, tuple.Object)
 	if err != nil {
 		return err
 	}
@@ -128,14 +154,31 @@ func (t Tuples) Remove(ctx context.Context, tuple doorman.Tuple) error {
 	return nil
 }
 
+func (t Tuples) HasTuplesForType(ctx context.Context, typ string) (bool, error) {
+	query := `
+		select exists(
+			select 1
+			from tuples
+			where tenant = $2 and (substr(subject, 1, length($1)) = $1 or substr(object, 1, length($1)) = $1)
+		)
+	`
+
+	var exists bool
+	if err := t.conn.QueryRow(ctx, query, typ+":", t.tenant).Scan(&exists); err != nil {
+		return false, fmt.Errorf("query failed: %w", err)
+	}
+
+	return exists, nil
+}
+
 func (t Tuples) ListTuplesForRole(ctx context.Context, role string) ([]doorman.Tuple, error) {
 	query := `
-		select subject, object
+		select subject, object, expires_at, condition
 		from tuples
-		where role = $1
+		where (tenant, role) = ($1, $2)
 	`
 
-	rows, err := t.conn.Query(ctx, query, role)
+	rows, err := t.conn.Query(ctx, query, t.tenant, role)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -143,7 +186,30 @@ func (t Tuples) ListTuplesForRole(ctx context.Context, role string) ([]doorman.T
 	var tuples []doorman.Tuple
 	for rows.Next() {
 		t := doorman.Tuple{Role: role}
-		if err := rows.Scan(&t.Subject, &t.Object); err != nil {
+		if err := rows.Scan(&t.Subject, &t.Object, &t.ExpiresAt, &t.Condition); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tuples = append(tuples, t)
+	}
+	return tuples, nil
+}
+
+func (t Tuples) ListExpiredTuples(ctx context.Context, before time.Time) ([]doorman.Tuple, error) {
+	query := `
+		select subject, role, object, expires_at, condition
+		from tuples
+		where tenant = $1 and expires_at < $2
+	`
+
+	rows, err := t.conn.Query(ctx, query, t.tenant, before)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	tuples := []doorman.Tuple{}
+	for rows.Next() {
+		t := doorman.Tuple{}
+		if err := rows.Scan(&t.Subject, &t.Role, &t.Object, &t.ExpiresAt, &t.Condition); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		tuples = append(tuples, t)
@@ -152,46 +218,95 @@ func (t Tuples) ListTuplesForRole(ctx context.Context, role string) ([]doorman.T
 }
 
 func (t Tuples) ListConnected(ctx context.Context, subject doorman.Object, inverted bool) ([]doorman.Path, error) {
+	// A path continues from an object to the tuples of the subject set of the role it was reached with,
+	// e.g. from group:eng reached with group:maintainer to the ones of group:eng#maintainer,
+	// with a connection to the subject set without a role in between
 	query := `
 		with recursive connections as (
 			select
-				object, array_append(array_append('{}'::text[], role), object) as via
+				object,
+				case when left(role, strpos(role, ':')) = left(object, strpos(object, ':')) then object || '#' || substr(role, strpos(role, ':') + 1) end as subject_set,
+				array[role, object, coalesce(to_json(expires_at) #>> '{}', ''), condition] as via
 			from tuples
-			where subject = $1
+			where (tenant, subject) = ($2, $1)
 
 			union
 
-			select next.object, array_append(array_append(prev.via, next.role), next.object)
+			select
+				next.object,
+				case when left(next.role, strpos(next.role, ':')) = left(next.object, strpos(next.object, ':')) then next.object || '#' || substr(next.role, strpos(next.role, ':') + 1) end,
+				prev.via
+					|| case when next.subject = prev.subject_set then array['', next.subject, '', ''] else array[]::text[] end
+					|| array[next.role, next.object, coalesce(to_json(next.expires_at) #>> '{}', ''), next.condition]
 			from tuples next
 			inner join
-				connections prev on prev.object = next.subject
-			where next.object != $1
+				connections prev on prev.object = next.subject or prev.subject_set = next.subject
+			where next.tenant = $2 and next.object != $1
 		) select via from connections
 	`
 
+	// Inverted, a path continues from a subject set to the holders of its role on the object
 	if inverted {
-		query = `
+		query = invertedConnections + ` select via from inverted_connections`
+	}
+
+	rows, err := t.conn.Query(ctx, query, subject, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("exec failed: %w", err)
+	}
+
+	return scanPaths(rows)
+}
+
+func (t Tuples) ListSubjectPaths(ctx context.Context, object, after doorman.Object, limit int) ([]doorman.Path, error) {
+	query := invertedConnections + `
+		select via from inverted_connections
+		where subject like '%:*' or subject in (
+			select distinct subject from inverted_connections
+			where subject > $3
+			order by subject
+			limit $4
+		)
+		order by subject, array_length(via, 1)
+	`
+
+	rows, err := t.conn.Query(ctx, query, object, t.tenant, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("exec failed: %w", err)
+	}
+
+	return scanPaths(rows)
+}
+
+// invertedConnections is the recursive part of the inverted ListConnected query, with the subject as $1 and the tenant as $2.
+const invertedConnections = `
 		with recursive inverted_connections as (
 			select
-				subject, array_append(array_append('{}'::text[], role), subject) as via
+				subject,
+				nullif(split_part(subject, '#', 1), subject) as set_object,
+				split_part(subject, ':', 1) || ':' || split_part(subject, '#', 2) as set_role,
+				array[role, subject, coalesce(to_json(expires_at) #>> '{}', ''), condition] as via
 			from tuples
-			where object = $1
+			where (tenant, object) = ($2, $1)
 
 			union
 
-			select next.subject, array_append(array_append(prev.via, next.role), next.subject)
+			select
+				next.subject,
+				nullif(split_part(next.subject, '#', 1), next.subject),
+				split_part(next.subject, ':', 1) || ':' || split_part(next.subject, '#', 2),
+				prev.via
+					|| case when next.object = prev.set_object then array['', next.object, '', ''] else array[]::text[] end
+					|| array[next.role, next.subject, coalesce(to_json(next.expires_at) #>> '{}', ''), next.condition]
 			from tuples next
 			inner join
-				inverted_connections prev on prev.subject = next.object
-			where next.subject != $1
-		) select via from inverted_connections
-	`
-	}
+				inverted_connections prev on prev.subject = next.object or (prev.set_object = next.object and prev.set_role = next.role)
+			where next.tenant = $2 and next.subject != $1
+		)
+`
 
-	rows, err := t.conn.Query(ctx, query, subject)
-	if err != nil {
-		return nil, fmt.Errorf("exec failed: %w", err)
-	}
+func scanPaths(rows pgx.Rows) ([]doorman.Path, error) {
+	defer rows.Close()
 
 	var paths []doorman.Path
 	for rows.Next() {
@@ -199,40 +314,69 @@ func (t Tuples) ListConnected(ctx context.Context, subject doorman.Object, inver
 		if err := rows.Scan(&via); err != nil {
 			return nil, fmt.Errorf("row scan failed: %w", err)
 		}
-		path := make([]doorman.Connection, len(via)/2)
-		for i := 0; i < len(via); i += 2 {
-			path[i/2] = doorman.Connection{Role: via[i], Object: doorman.Object(via[i+1])}
+		path, err := pathFromVia(via)
+		if err != nil {
+			return nil, err
 		}
 		paths = append(paths, path)
 	}
 
-	return paths, nil
+	return paths, rows.Err()
+}
+
+// pathFromVia parses the (role, object, expires_at, condition) tuples built by ListConnected.
+// expires_at is empty if the tuple does not expire, as text[] can't hold a null.
+func pathFromVia(via []string) (doorman.Path, error) {
+	path := make([]doorman.Connection, len(via)/4)
+	for i := 0; i < len(via); i += 4 {
+		conn := doorman.Connection{Role: via[i], Object: doorman.Object(via[i+1]), Condition: via[i+3]}
+		if via[i+2] != "" {
+			expiresAt, err := time.Parse(time.RFC3339Nano, via[i+2])
+			if err != nil {
+				return nil, fmt.Errorf("parsing expires_at failed: %w", err)
+			}
+			conn.ExpiresAt = &expiresAt
+		}
+		path[i/4] = conn
+	}
+	return path, nil
 }
 
-func listConnectedTiny(ctx context.Context, tx querier, subject doorman.Object) ([]doorman.Object, error) {
+// listConnectedTiny lists the objects and subject sets reachable from subject, reached with role.
+func listConnectedTiny(ctx context.Context, tx querier, tenant string, subject doorman.Object, role string) ([]doorman.Object, error) {
 	query := `
 		with recursive connections as (
 			select
-				object
+				object,
+				case when left(role, strpos(role, ':')) = left(object, strpos(object, ':')) then object || '#' || substr(role, strpos(role, ':') + 1) end as subject_set
 			from tuples
-			where subject = $1
+			where tenant = $3 and subject in ($1, $2)
 
 			union
 
-			select next.object
+			select
+				next.object,
+				case when left(next.role, strpos(next.role, ':')) = left(next.object, strpos(next.object, ':')) then next.object || '#' || substr(next.role, strpos(next.role, ':') + 1) end
 			from tuples next
 			inner join
-				connections prev on prev.object = next.subject
-			where next.object != $1
-		) select object from connections
+				connections prev on prev.object = next.subject or prev.subject_set = next.subject
+			where next.tenant = $3 and next.object != $1
+		)
+		select object from connections
+		union
+		select subject_set from connections where subject_set is not null
 	`
 
-	rows, err := tx.Query(ctx, query, subject)
+	set, _ := subject.SubjectSet(role)
+	rows, err := tx.Query(ctx, query, subject, set, tenant)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
 
 	var objects []doorman.Object
+	if set != "" {
+		objects = append(objects, set)
+	}
 	for rows.Next() {
 		o := doorman.Object("")
 		if err := rows.Scan(&o); err != nil {
@@ -244,6 +388,6 @@ func listConnectedTiny(ctx context.Context, tx querier, subject doorman.Object)
 	return objects, nil
 }
 
-func NewTuples(conn querier) Tuples {
-	return Tuples{conn}
+func NewTuples(conn querier, tenant string) Tuples {
+	return Tuples{conn: conn, tenant: tenant}
 }
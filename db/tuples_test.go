@@ -2,22 +2,49 @@ package db
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/xid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/td0m/doorman"
 )
 
-var conn *pgxpool.Pool
+// set depending on DOORMAN_TEST_STORE
+var (
+	conn      *pgxpool.Pool
+	sqliteDir string
+)
+
+// newStore returns an empty store, in memory unless DOORMAN_TEST_STORE is set.
+func newStore() Store {
+	switch {
+	case conn != nil:
+		cleanup(conn)
+		return NewPostgres(conn)
+	case sqliteDir != "":
+		store, err := OpenSQLite(context.Background(), filepath.Join(sqliteDir, xid.New().String()+".db"))
+		if err != nil {
+			panic(err)
+		}
+		return store
+	default:
+		return NewMemory()
+	}
+}
 
 func cleanup(conn *pgxpool.Pool) {
 	ctx := context.Background()
 	_, err := conn.Exec(ctx, `
 		delete from tuples;
 		delete from roles;
-		delete from objects;
+		delete from types;
+		delete from changes;
+		delete from set_parents;
+		delete from set_subsets;
 	`)
 
 	if err != nil {
@@ -27,21 +54,35 @@ func cleanup(conn *pgxpool.Pool) {
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
-	pool, err := pgxpool.New(ctx, "")
-	if err != nil {
-		panic(err)
+
+	switch os.Getenv("DOORMAN_TEST_STORE") {
+	case "", "memory":
+	case "postgres":
+		pool, err := pgxpool.New(ctx, "")
+		if err != nil {
+			panic(err)
+		}
+		conn = pool
+	case "sqlite":
+		dir, err := os.MkdirTemp("", "doorman")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(dir)
+		sqliteDir = dir
+	default:
+		panic("unknown DOORMAN_TEST_STORE: " + os.Getenv("DOORMAN_TEST_STORE"))
 	}
-	conn = pool
 
 	m.Run()
 }
 
 func TestTuplesCreate(t *testing.T) {
 	ctx := context.Background()
-	cleanup(conn)
-	tuples := NewTuples(conn)
+	store := newStore()
+	tuples := store.Tuples()
 	objects := NewObjects(conn)
-	roles := NewRoles(conn)
+	roles := store.Roles()
 
 	// Make objects and roles
 
@@ -68,10 +109,10 @@ func TestTuplesCreate(t *testing.T) {
 
 func TestTuplesRemove(t *testing.T) {
 	ctx := context.Background()
-	cleanup(conn)
-	tuples := NewTuples(conn)
+	store := newStore()
+	tuples := store.Tuples()
 	objects := NewObjects(conn)
-	roles := NewRoles(conn)
+	roles := store.Roles()
 
 	alice := doorman.Object("user:alice")
 	member := doorman.Role{
@@ -103,10 +144,10 @@ func TestTuplesRemove(t *testing.T) {
 
 func TestTuplesListConnected(t *testing.T) {
 	ctx := context.Background()
-	cleanup(conn)
-	tuples := NewTuples(conn)
+	store := newStore()
+	tuples := store.Tuples()
 	objects := NewObjects(conn)
-	roles := NewRoles(conn)
+	roles := store.Roles()
 
 	// Init objects
 	alice := doorman.Object("user:alice")
@@ -176,3 +217,71 @@ func TestTuplesListConnected(t *testing.T) {
 		}, paths[2])
 	})
 }
+
+func TestTenants(t *testing.T) {
+	ctx := context.Background()
+	store := newStore()
+	acme := store.Tenant("acme")
+
+	alice := doorman.Object("user:alice")
+	admins := doorman.Object("group:admins")
+
+	// The same role can be added to both tenants
+	require.NoError(t, store.Roles().Add(ctx, doorman.NewRole("member", []doorman.Verb{"foo"})))
+	require.NoError(t, acme.Roles().Add(ctx, doorman.NewRole("member", []doorman.Verb{"bar"})))
+
+	require.NoError(t, acme.Tuples().Add(ctx, doorman.NewTuple(alice, "member", admins)))
+	require.NoError(t, acme.Changes().Add(ctx, doorman.Change{ID: xid.New().String(), Type: "GRANTED", Payload: []byte("{}")}))
+
+	t.Run("Tenants don't see each other's data", func(t *testing.T) {
+		role, err := store.Roles().Retrieve(ctx, "member")
+		require.NoError(t, err)
+		assert.Equal(t, []doorman.Verb{"foo"}, role.Verbs)
+
+		role, err = acme.Roles().Retrieve(ctx, "member")
+		require.NoError(t, err)
+		assert.Equal(t, []doorman.Verb{"bar"}, role.Verbs)
+
+		parents, err := store.Tuples().ListParents(ctx, alice)
+		require.NoError(t, err)
+		assert.Empty(t, parents)
+
+		paths, err := acme.Tuples().ListConnected(ctx, alice, false)
+		require.NoError(t, err)
+		assert.Equal(t, []doorman.Path{{{Role: "member", Object: admins}}}, paths)
+
+		changes, err := store.Changes().List(ctx, ChangeFilter{})
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+
+		_, err = store.Changes().ClaimPending(ctx)
+		assert.ErrorIs(t, err, ErrNoChanges)
+	})
+
+	t.Run("A claim is undone by rolling back", func(t *testing.T) {
+		tx, err := acme.Begin(ctx)
+		require.NoError(t, err)
+		c, err := acme.Changes().WithTx(tx).ClaimPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "processed", c.Status)
+		require.NoError(t, tx.Rollback(ctx))
+
+		pending := "pending"
+		changes, err := acme.Changes().List(ctx, ChangeFilter{Status: &pending})
+		require.NoError(t, err)
+		assert.Len(t, changes, 1)
+	})
+
+	t.Run("Roles of another tenant can't be granted", func(t *testing.T) {
+		err := store.Tenant("other").Tuples().Add(ctx, doorman.NewTuple(alice, "member", admins))
+		assert.Error(t, err)
+	})
+
+	t.Run("Tenants lists the ones with anything stored", func(t *testing.T) {
+		require.NoError(t, store.Tenant("beta").Roles().Add(ctx, doorman.NewRole("member", []doorman.Verb{"foo"})))
+
+		tenants, err := store.Tenants(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", "acme", "beta"}, tenants)
+	})
+}
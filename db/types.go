@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/td0m/doorman"
+)
+
+type Types struct {
+	conn   querier
+	tenant string
+}
+
+func (t Types) WithTx(tx Tx) TypeStore {
+	return &Types{conn: tx.(pgx.Tx), tenant: t.tenant}
+}
+
+func (t Types) List(ctx context.Context) ([]doorman.Type, error) {
+	query := `
+		select id, nestable
+		from types
+		where tenant = $1
+		order by id
+	`
+
+	rows, err := t.conn.Query(ctx, query, t.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	types := []doorman.Type{}
+	for rows.Next() {
+		typ := doorman.Type{}
+		if err := rows.Scan(&typ.ID, &typ.Nestable); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		types = append(types, typ)
+	}
+
+	return types, rows.Err()
+}
+
+func (t Types) Retrieve(ctx context.Context, id string) (*doorman.Type, error) {
+	query := `
+		select id, nestable
+		from types
+		where (tenant, id) = ($1, $2)
+	`
+
+	typ := doorman.Type{}
+	if err := t.conn.QueryRow(ctx, query, t.tenant, id).Scan(&typ.ID, &typ.Nestable); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrInvalidType
+		}
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	return &typ, nil
+}
+
+func (t Types) Remove(ctx context.Context, id string) error {
+	query := `
+		delete from types where (tenant, id) = ($1, $2)
+	`
+
+	if _, err := t.conn.Exec(ctx, query, t.tenant, id); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
+	}
+
+	return nil
+}
+
+func (t Types) Upsert(ctx context.Context, typ doorman.Type) error {
+	query := `
+		insert into types(tenant, id, nestable)
+		values($1, $2, $3)
+		on conflict(tenant, id) do update
+			set nestable = $3
+	`
+
+	if _, err := t.conn.Exec(ctx, query, t.tenant, typ.ID, typ.Nestable); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
+	}
+
+	return nil
+}
+
+func NewTypes(conn querier, tenant string) Types {
+	return Types{conn: conn, tenant: tenant}
+}
@@ -20,14 +20,25 @@ const _ = grpc.SupportPackageIsVersion7
 
 const (
 	Doorman_Check_FullMethodName        = "/doorman.Doorman/Check"
+	Doorman_CheckAt_FullMethodName      = "/doorman.Doorman/CheckAt"
+	Doorman_BatchCheck_FullMethodName   = "/doorman.Doorman/BatchCheck"
 	Doorman_Grant_FullMethodName        = "/doorman.Doorman/Grant"
 	Doorman_Revoke_FullMethodName       = "/doorman.Doorman/Revoke"
 	Doorman_ListRoles_FullMethodName    = "/doorman.Doorman/ListRoles"
 	Doorman_RemoveRole_FullMethodName   = "/doorman.Doorman/RemoveRole"
 	Doorman_UpsertRole_FullMethodName   = "/doorman.Doorman/UpsertRole"
+	Doorman_ListTypes_FullMethodName    = "/doorman.Doorman/ListTypes"
+	Doorman_RemoveType_FullMethodName   = "/doorman.Doorman/RemoveType"
+	Doorman_UpsertType_FullMethodName   = "/doorman.Doorman/UpsertType"
 	Doorman_ListObjects_FullMethodName  = "/doorman.Doorman/ListObjects"
+	Doorman_ListSubjects_FullMethodName = "/doorman.Doorman/ListSubjects"
 	Doorman_Changes_FullMethodName      = "/doorman.Doorman/Changes"
+	Doorman_Audit_FullMethodName        = "/doorman.Doorman/Audit"
+	Doorman_Watch_FullMethodName        = "/doorman.Doorman/Watch"
 	Doorman_RebuildCache_FullMethodName = "/doorman.Doorman/RebuildCache"
+	Doorman_Export_FullMethodName       = "/doorman.Doorman/Export"
+	Doorman_Import_FullMethodName       = "/doorman.Doorman/Import"
+	Doorman_Apply_FullMethodName        = "/doorman.Doorman/Apply"
 )
 
 // DoormanClient is the client API for Doorman service.
@@ -35,14 +46,33 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type DoormanClient interface {
 	Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	// CheckAt checks as of a past instant, rebuilding the tuples and roles from the changes made until then.
+	// The path is always explained.
+	CheckAt(ctx context.Context, in *CheckAtRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	BatchCheck(ctx context.Context, in *BatchCheckRequest, opts ...grpc.CallOption) (*BatchCheckResponse, error)
 	Grant(ctx context.Context, in *GrantRequest, opts ...grpc.CallOption) (*GrantResponse, error)
 	Revoke(ctx context.Context, in *RevokeRequest, opts ...grpc.CallOption) (*RevokeResponse, error)
 	ListRoles(ctx context.Context, in *ListRolesRequest, opts ...grpc.CallOption) (*ListRolesResponse, error)
 	RemoveRole(ctx context.Context, in *RemoveRoleRequest, opts ...grpc.CallOption) (*Role, error)
 	UpsertRole(ctx context.Context, in *UpsertRoleRequest, opts ...grpc.CallOption) (*Role, error)
+	ListTypes(ctx context.Context, in *ListTypesRequest, opts ...grpc.CallOption) (*ListTypesResponse, error)
+	RemoveType(ctx context.Context, in *RemoveTypeRequest, opts ...grpc.CallOption) (*Type, error)
+	UpsertType(ctx context.Context, in *UpsertTypeRequest, opts ...grpc.CallOption) (*Type, error)
 	ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (*ListObjectsResponse, error)
+	ListSubjects(ctx context.Context, in *ListSubjectsRequest, opts ...grpc.CallOption) (*ListSubjectsResponse, error)
 	Changes(ctx context.Context, in *ChangesRequest, opts ...grpc.CallOption) (*ChangesResponse, error)
+	// Audit lists the changes with who made them and why, oldest first.
+	Audit(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (*AuditResponse, error)
+	// Watch streams changes as they are committed, starting after the given change if set.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Doorman_WatchClient, error)
+	// RebuildCache rebuilds the cache of the tenant by processing all of its changes again.
 	RebuildCache(ctx context.Context, in *RebuildCacheRequest, opts ...grpc.CallOption) (*RebuildCacheResponse, error)
+	// Export streams every type, role and tuple, in the order Import needs them in.
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Doorman_ExportClient, error)
+	// Import loads the records streamed by Export in a single transaction.
+	Import(ctx context.Context, opts ...grpc.CallOption) (Doorman_ImportClient, error)
+	// Apply makes the roles and tuples match the manifest in a single transaction, and returns the differences.
+	Apply(ctx context.Context, in *ApplyRequest, opts ...grpc.CallOption) (*ApplyResponse, error)
 }
 
 type doormanClient struct {
@@ -62,6 +92,24 @@ func (c *doormanClient) Check(ctx context.Context, in *CheckRequest, opts ...grp
 	return out, nil
 }
 
+func (c *doormanClient) CheckAt(ctx context.Context, in *CheckAtRequest, opts ...grpc.CallOption) (*CheckResponse, error) {
+	out := new(CheckResponse)
+	err := c.cc.Invoke(ctx, Doorman_CheckAt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *doormanClient) BatchCheck(ctx context.Context, in *BatchCheckRequest, opts ...grpc.CallOption) (*BatchCheckResponse, error) {
+	out := new(BatchCheckResponse)
+	err := c.cc.Invoke(ctx, Doorman_BatchCheck_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *doormanClient) Grant(ctx context.Context, in *GrantRequest, opts ...grpc.CallOption) (*GrantResponse, error) {
 	out := new(GrantResponse)
 	err := c.cc.Invoke(ctx, Doorman_Grant_FullMethodName, in, out, opts...)
@@ -107,6 +155,33 @@ func (c *doormanClient) UpsertRole(ctx context.Context, in *UpsertRoleRequest, o
 	return out, nil
 }
 
+func (c *doormanClient) ListTypes(ctx context.Context, in *ListTypesRequest, opts ...grpc.CallOption) (*ListTypesResponse, error) {
+	out := new(ListTypesResponse)
+	err := c.cc.Invoke(ctx, Doorman_ListTypes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *doormanClient) RemoveType(ctx context.Context, in *RemoveTypeRequest, opts ...grpc.CallOption) (*Type, error) {
+	out := new(Type)
+	err := c.cc.Invoke(ctx, Doorman_RemoveType_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *doormanClient) UpsertType(ctx context.Context, in *UpsertTypeRequest, opts ...grpc.CallOption) (*Type, error) {
+	out := new(Type)
+	err := c.cc.Invoke(ctx, Doorman_UpsertType_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *doormanClient) ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (*ListObjectsResponse, error) {
 	out := new(ListObjectsResponse)
 	err := c.cc.Invoke(ctx, Doorman_ListObjects_FullMethodName, in, out, opts...)
@@ -116,6 +191,15 @@ func (c *doormanClient) ListObjects(ctx context.Context, in *ListObjectsRequest,
 	return out, nil
 }
 
+func (c *doormanClient) ListSubjects(ctx context.Context, in *ListSubjectsRequest, opts ...grpc.CallOption) (*ListSubjectsResponse, error) {
+	out := new(ListSubjectsResponse)
+	err := c.cc.Invoke(ctx, Doorman_ListSubjects_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *doormanClient) Changes(ctx context.Context, in *ChangesRequest, opts ...grpc.CallOption) (*ChangesResponse, error) {
 	out := new(ChangesResponse)
 	err := c.cc.Invoke(ctx, Doorman_Changes_FullMethodName, in, out, opts...)
@@ -125,6 +209,47 @@ func (c *doormanClient) Changes(ctx context.Context, in *ChangesRequest, opts ..
 	return out, nil
 }
 
+func (c *doormanClient) Audit(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (*AuditResponse, error) {
+	out := new(AuditResponse)
+	err := c.cc.Invoke(ctx, Doorman_Audit_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *doormanClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Doorman_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Doorman_ServiceDesc.Streams[0], Doorman_Watch_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &doormanWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Doorman_WatchClient interface {
+	Recv() (*Change, error)
+	grpc.ClientStream
+}
+
+type doormanWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *doormanWatchClient) Recv() (*Change, error) {
+	m := new(Change)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *doormanClient) RebuildCache(ctx context.Context, in *RebuildCacheRequest, opts ...grpc.CallOption) (*RebuildCacheResponse, error) {
 	out := new(RebuildCacheResponse)
 	err := c.cc.Invoke(ctx, Doorman_RebuildCache_FullMethodName, in, out, opts...)
@@ -134,19 +259,113 @@ func (c *doormanClient) RebuildCache(ctx context.Context, in *RebuildCacheReques
 	return out, nil
 }
 
+func (c *doormanClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Doorman_ExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Doorman_ServiceDesc.Streams[1], Doorman_Export_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &doormanExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Doorman_ExportClient interface {
+	Recv() (*Record, error)
+	grpc.ClientStream
+}
+
+type doormanExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *doormanExportClient) Recv() (*Record, error) {
+	m := new(Record)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *doormanClient) Import(ctx context.Context, opts ...grpc.CallOption) (Doorman_ImportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Doorman_ServiceDesc.Streams[2], Doorman_Import_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &doormanImportClient{stream}
+	return x, nil
+}
+
+type Doorman_ImportClient interface {
+	Send(*ImportRequest) error
+	CloseAndRecv() (*ImportResponse, error)
+	grpc.ClientStream
+}
+
+type doormanImportClient struct {
+	grpc.ClientStream
+}
+
+func (x *doormanImportClient) Send(m *ImportRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *doormanImportClient) CloseAndRecv() (*ImportResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *doormanClient) Apply(ctx context.Context, in *ApplyRequest, opts ...grpc.CallOption) (*ApplyResponse, error) {
+	out := new(ApplyResponse)
+	err := c.cc.Invoke(ctx, Doorman_Apply_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DoormanServer is the server API for Doorman service.
 // All implementations must embed UnimplementedDoormanServer
 // for forward compatibility
 type DoormanServer interface {
 	Check(context.Context, *CheckRequest) (*CheckResponse, error)
+	// CheckAt checks as of a past instant, rebuilding the tuples and roles from the changes made until then.
+	// The path is always explained.
+	CheckAt(context.Context, *CheckAtRequest) (*CheckResponse, error)
+	BatchCheck(context.Context, *BatchCheckRequest) (*BatchCheckResponse, error)
 	Grant(context.Context, *GrantRequest) (*GrantResponse, error)
 	Revoke(context.Context, *RevokeRequest) (*RevokeResponse, error)
 	ListRoles(context.Context, *ListRolesRequest) (*ListRolesResponse, error)
 	RemoveRole(context.Context, *RemoveRoleRequest) (*Role, error)
 	UpsertRole(context.Context, *UpsertRoleRequest) (*Role, error)
+	ListTypes(context.Context, *ListTypesRequest) (*ListTypesResponse, error)
+	RemoveType(context.Context, *RemoveTypeRequest) (*Type, error)
+	UpsertType(context.Context, *UpsertTypeRequest) (*Type, error)
 	ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error)
+	ListSubjects(context.Context, *ListSubjectsRequest) (*ListSubjectsResponse, error)
 	Changes(context.Context, *ChangesRequest) (*ChangesResponse, error)
+	// Audit lists the changes with who made them and why, oldest first.
+	Audit(context.Context, *AuditRequest) (*AuditResponse, error)
+	// Watch streams changes as they are committed, starting after the given change if set.
+	Watch(*WatchRequest, Doorman_WatchServer) error
+	// RebuildCache rebuilds the cache of the tenant by processing all of its changes again.
 	RebuildCache(context.Context, *RebuildCacheRequest) (*RebuildCacheResponse, error)
+	// Export streams every type, role and tuple, in the order Import needs them in.
+	Export(*ExportRequest, Doorman_ExportServer) error
+	// Import loads the records streamed by Export in a single transaction.
+	Import(Doorman_ImportServer) error
+	// Apply makes the roles and tuples match the manifest in a single transaction, and returns the differences.
+	Apply(context.Context, *ApplyRequest) (*ApplyResponse, error)
 	mustEmbedUnimplementedDoormanServer()
 }
 
@@ -157,6 +376,12 @@ type UnimplementedDoormanServer struct {
 func (UnimplementedDoormanServer) Check(context.Context, *CheckRequest) (*CheckResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
 }
+func (UnimplementedDoormanServer) CheckAt(context.Context, *CheckAtRequest) (*CheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckAt not implemented")
+}
+func (UnimplementedDoormanServer) BatchCheck(context.Context, *BatchCheckRequest) (*BatchCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCheck not implemented")
+}
 func (UnimplementedDoormanServer) Grant(context.Context, *GrantRequest) (*GrantResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Grant not implemented")
 }
@@ -172,15 +397,42 @@ func (UnimplementedDoormanServer) RemoveRole(context.Context, *RemoveRoleRequest
 func (UnimplementedDoormanServer) UpsertRole(context.Context, *UpsertRoleRequest) (*Role, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpsertRole not implemented")
 }
+func (UnimplementedDoormanServer) ListTypes(context.Context, *ListTypesRequest) (*ListTypesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTypes not implemented")
+}
+func (UnimplementedDoormanServer) RemoveType(context.Context, *RemoveTypeRequest) (*Type, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveType not implemented")
+}
+func (UnimplementedDoormanServer) UpsertType(context.Context, *UpsertTypeRequest) (*Type, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpsertType not implemented")
+}
 func (UnimplementedDoormanServer) ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListObjects not implemented")
 }
+func (UnimplementedDoormanServer) ListSubjects(context.Context, *ListSubjectsRequest) (*ListSubjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSubjects not implemented")
+}
 func (UnimplementedDoormanServer) Changes(context.Context, *ChangesRequest) (*ChangesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Changes not implemented")
 }
+func (UnimplementedDoormanServer) Audit(context.Context, *AuditRequest) (*AuditResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Audit not implemented")
+}
+func (UnimplementedDoormanServer) Watch(*WatchRequest, Doorman_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
 func (UnimplementedDoormanServer) RebuildCache(context.Context, *RebuildCacheRequest) (*RebuildCacheResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RebuildCache not implemented")
 }
+func (UnimplementedDoormanServer) Export(*ExportRequest, Doorman_ExportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedDoormanServer) Import(Doorman_ImportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedDoormanServer) Apply(context.Context, *ApplyRequest) (*ApplyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Apply not implemented")
+}
 func (UnimplementedDoormanServer) mustEmbedUnimplementedDoormanServer() {}
 
 // UnsafeDoormanServer may be embedded to opt out of forward compatibility for this service.
@@ -212,6 +464,42 @@ func _Doorman_Check_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Doorman_CheckAt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckAtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoormanServer).CheckAt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doorman_CheckAt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoormanServer).CheckAt(ctx, req.(*CheckAtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Doorman_BatchCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoormanServer).BatchCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doorman_BatchCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoormanServer).BatchCheck(ctx, req.(*BatchCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Doorman_Grant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GrantRequest)
 	if err := dec(in); err != nil {
@@ -302,6 +590,60 @@ func _Doorman_UpsertRole_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Doorman_ListTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTypesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoormanServer).ListTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doorman_ListTypes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoormanServer).ListTypes(ctx, req.(*ListTypesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Doorman_RemoveType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoormanServer).RemoveType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doorman_RemoveType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoormanServer).RemoveType(ctx, req.(*RemoveTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Doorman_UpsertType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpsertTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoormanServer).UpsertType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doorman_UpsertType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoormanServer).UpsertType(ctx, req.(*UpsertTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Doorman_ListObjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListObjectsRequest)
 	if err := dec(in); err != nil {
@@ -320,6 +662,24 @@ func _Doorman_ListObjects_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Doorman_ListSubjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSubjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoormanServer).ListSubjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doorman_ListSubjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoormanServer).ListSubjects(ctx, req.(*ListSubjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Doorman_Changes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ChangesRequest)
 	if err := dec(in); err != nil {
@@ -338,6 +698,45 @@ func _Doorman_Changes_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Doorman_Audit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoormanServer).Audit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doorman_Audit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoormanServer).Audit(ctx, req.(*AuditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Doorman_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DoormanServer).Watch(m, &doormanWatchServer{stream})
+}
+
+type Doorman_WatchServer interface {
+	Send(*Change) error
+	grpc.ServerStream
+}
+
+type doormanWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *doormanWatchServer) Send(m *Change) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Doorman_RebuildCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RebuildCacheRequest)
 	if err := dec(in); err != nil {
@@ -356,6 +755,71 @@ func _Doorman_RebuildCache_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Doorman_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DoormanServer).Export(m, &doormanExportServer{stream})
+}
+
+type Doorman_ExportServer interface {
+	Send(*Record) error
+	grpc.ServerStream
+}
+
+type doormanExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *doormanExportServer) Send(m *Record) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Doorman_Import_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DoormanServer).Import(&doormanImportServer{stream})
+}
+
+type Doorman_ImportServer interface {
+	SendAndClose(*ImportResponse) error
+	Recv() (*ImportRequest, error)
+	grpc.ServerStream
+}
+
+type doormanImportServer struct {
+	grpc.ServerStream
+}
+
+func (x *doormanImportServer) SendAndClose(m *ImportResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *doormanImportServer) Recv() (*ImportRequest, error) {
+	m := new(ImportRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Doorman_Apply_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApplyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoormanServer).Apply(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doorman_Apply_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoormanServer).Apply(ctx, req.(*ApplyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Doorman_ServiceDesc is the grpc.ServiceDesc for Doorman service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -367,6 +831,14 @@ var Doorman_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Check",
 			Handler:    _Doorman_Check_Handler,
 		},
+		{
+			MethodName: "CheckAt",
+			Handler:    _Doorman_CheckAt_Handler,
+		},
+		{
+			MethodName: "BatchCheck",
+			Handler:    _Doorman_BatchCheck_Handler,
+		},
 		{
 			MethodName: "Grant",
 			Handler:    _Doorman_Grant_Handler,
@@ -387,19 +859,59 @@ var Doorman_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpsertRole",
 			Handler:    _Doorman_UpsertRole_Handler,
 		},
+		{
+			MethodName: "ListTypes",
+			Handler:    _Doorman_ListTypes_Handler,
+		},
+		{
+			MethodName: "RemoveType",
+			Handler:    _Doorman_RemoveType_Handler,
+		},
+		{
+			MethodName: "UpsertType",
+			Handler:    _Doorman_UpsertType_Handler,
+		},
 		{
 			MethodName: "ListObjects",
 			Handler:    _Doorman_ListObjects_Handler,
 		},
+		{
+			MethodName: "ListSubjects",
+			Handler:    _Doorman_ListSubjects_Handler,
+		},
 		{
 			MethodName: "Changes",
 			Handler:    _Doorman_Changes_Handler,
 		},
+		{
+			MethodName: "Audit",
+			Handler:    _Doorman_Audit_Handler,
+		},
 		{
 			MethodName: "RebuildCache",
 			Handler:    _Doorman_RebuildCache_Handler,
 		},
+		{
+			MethodName: "Apply",
+			Handler:    _Doorman_Apply_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Doorman_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Export",
+			Handler:       _Doorman_Export_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Import",
+			Handler:       _Doorman_Import_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "doorman.proto",
 }
@@ -2,19 +2,86 @@ package doorman
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
+var typePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Type is the part of an object id before the colon, e.g. user in user:alice.
+type Type struct {
+	ID string `json:"id"`
+	// members of objects of a nestable type, e.g. groups, inherit their permissions.
+	// Only nestable objects can be in between a subject and an object on a path.
+	Nestable bool `json:"nestable,omitempty"`
+}
+
+func (t Type) Validate() error {
+	if !typePattern.MatchString(t.ID) {
+		return fmt.Errorf("invalid type %q, must match %s", t.ID, typePattern)
+	}
+
+	return nil
+}
+
 type Object string
 
 func (o Object) Validate() error {
-	if !strings.Contains(string(o), ":") {
-		return fmt.Errorf("invalid object format")
+	typ, value, ok := strings.Cut(string(o), ":")
+	if !ok {
+		return fmt.Errorf("invalid object format, expected type:value")
+	}
+
+	if err := (Type{ID: typ}).Validate(); err != nil {
+		return err
+	}
+
+	if value == "" || strings.ContainsAny(value, " \t\r\n") {
+		return fmt.Errorf("invalid object value %q", value)
+	}
+
+	if value, role, ok := strings.Cut(value, "#"); ok {
+		if value == "" || value == "*" || !typePattern.MatchString(role) {
+			return fmt.Errorf("invalid subject set %q, expected type:value#role", o)
+		}
 	}
 
 	return nil
 }
 
+// SubjectSet is the subject that stands for the holders of the role on the object,
+// e.g. group:eng#maintainer for group:maintainer on group:eng.
+// Only roles named after the object's type have one.
+func (o Object) SubjectSet(roleID string) (Object, bool) {
+	name, ok := strings.CutPrefix(roleID, o.Type()+":")
+	if !ok || name == "" || o.IsSubjectSet() {
+		return "", false
+	}
+	return Object(string(o) + "#" + name), true
+}
+
+func (o Object) IsSubjectSet() bool {
+	return strings.Contains(string(o), "#")
+}
+
+// SplitSubjectSet returns the object and the role a subject set stands for.
+func (o Object) SplitSubjectSet() (Object, string, bool) {
+	obj, name, ok := strings.Cut(string(o), "#")
+	if !ok {
+		return "", "", false
+	}
+	return Object(obj), o.Type() + ":" + name, true
+}
+
+// Wildcard is the subject that stands for every object of the same type, e.g. user:*.
+func (o Object) Wildcard() Object {
+	return Object(o.Type() + ":*")
+}
+
+func (o Object) IsWildcard() bool {
+	return strings.HasSuffix(string(o), ":*")
+}
+
 func (o Object) Type() string {
 	return strings.SplitN(string(o), ":", 2)[0]
 }
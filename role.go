@@ -1,10 +1,27 @@
 package doorman
 
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/exp/slices"
+)
+
+var ErrRoleCycle = errors.New("roles can't include each other")
+
 type Verb string
 
 type Role struct {
-	ID    string
-	Verbs []Verb
+	ID    string `json:"id"`
+	Verbs []Verb `json:"verbs"`
+	// the roles whose verbs this role has too
+	Includes []string `json:"includes,omitempty"`
+	// a deny role takes its verbs away instead, see Set.Denied
+	Deny bool `json:"deny,omitempty"`
+	// a parent role makes its subject a child of the object, e.g. a document of a folder.
+	// Its verbs are the ones that flow from the object to the child, they aren't granted to the child itself.
+	Parent bool `json:"parent,omitempty"`
 }
 
 func NewRole(id string, optverbs ...[]Verb) Role {
@@ -14,3 +31,54 @@ func NewRole(id string, optverbs ...[]Verb) Role {
 	}
 	return Role{ID: id, Verbs: verbs}
 }
+
+// ResolveVerbs lists the verbs of the role and of the roles it includes, transitively.
+func ResolveVerbs(ctx context.Context, id string, r resolveRole) ([]Verb, error) {
+	roles, err := ResolveRoles(ctx, id, r)
+	if err != nil {
+		return nil, err
+	}
+
+	verbs := []Verb{}
+	for _, role := range roles {
+		verbs = append(verbs, role.Verbs...)
+	}
+	slices.Sort(verbs)
+	return slices.Compact(verbs), nil
+}
+
+// ResolveRoles lists the role and the roles it includes, transitively.
+// Fails with ErrRoleCycle if a role ends up including itself.
+func ResolveRoles(ctx context.Context, id string, r resolveRole) ([]Role, error) {
+	roles := []Role{}
+	done := map[string]bool{}
+
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		if slices.Contains(path, id) {
+			return fmt.Errorf("%w: %s", ErrRoleCycle, append(path, id))
+		}
+		if done[id] {
+			return nil
+		}
+
+		role, err := r(ctx, id)
+		if err != nil {
+			return fmt.Errorf("resolving role %q failed: %w", id, err)
+		}
+		for _, included := range role.Includes {
+			if err := visit(included, append(path, id)); err != nil {
+				return err
+			}
+		}
+
+		done[id] = true
+		roles = append(roles, *role)
+		return nil
+	}
+
+	if err := visit(id, nil); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
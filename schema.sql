@@ -8,26 +8,95 @@
 --   key text unique
 -- );
 
+-- every table is scoped to a tenant, the default one is empty
+
+create table types(
+  tenant text not null default '',
+  id text not null,
+  -- members of nestable objects, e.g. groups, inherit their permissions
+  nestable boolean not null default false,
+
+  primary key(tenant, id)
+);
+
+-- groups were nestable before any type could be, see db.DefaultTypes
+insert into types(tenant, id, nestable) values('', 'group', true);
+
 create table roles(
-  id text primary key,
-  verbs text[] not null default '{}'
+  tenant text not null default '',
+  id text not null,
+  verbs text[] not null default '{}',
+  -- ids of the roles whose verbs this role has too
+  includes text[] not null default '{}',
+  -- deny roles take their verbs away from the subjects, overriding any grants
+  deny boolean not null default false,
+  -- parent roles make their subjects children of the objects, which get the verbs of the role on the objects
+  parent boolean not null default false,
+
+  primary key(tenant, id)
 );
 
 create table tuples(
+  tenant text not null default '',
   subject text not null,
-  role text not null references roles(id),
+  role text not null,
   object text not null,
+  expires_at timestamptz,
+  -- the tuple only applies when it holds, see doorman.Condition
+  condition text not null default '',
 
-  primary key(subject, role, object)
+  primary key(tenant, subject, role, object),
+  constraint tuples_role_fkey foreign key(tenant, role) references roles(tenant, id)
 );
 
 -- already indexed for listing connections (from primary key), but need to support the same in reverse
-create index "tuples_idx_reverse_lookup" on tuples(object, role);
+create index "tuples_idx_reverse_lookup" on tuples(tenant, object, role);
+
+-- for revoking expired tuples
+create index "tuples_idx_expires_at" on tuples(tenant, expires_at) where expires_at is not null;
 
 create table changes(
+  tenant text not null default '',
   id text primary key,
   type text not null,
   payload jsonb not null,
   status text not null default 'pending',
-  created_at timestamptz not null default now()
+  created_at timestamptz not null default now(),
+  -- position in the log of the tenant, in the order the changes were committed, see db.Changes.Add
+  seq bigint not null,
+  actor text not null default '',
+  reason text not null default '',
+  request_id text not null default ''
+);
+
+-- for listing the changes of a tenant in order, e.g. to watch them
+create unique index "changes_idx_seq" on changes(tenant, seq);
+-- for listing and claiming the changes of a tenant
+create index "changes_idx_tenant" on changes(tenant, status);
+-- for auditing what an actor changed
+create index "changes_idx_actor" on changes(tenant, actor);
+
+-- materialized sets, see db.Sets
+create table set_parents(
+  tenant text not null default '',
+  subject text not null,
+  object text not null,
+  verb text not null,
+  expires_at timestamptz,
+  -- all of them have to hold, see doorman.Condition
+  conditions jsonb not null default '[]',
+
+  primary key(tenant, subject, object, verb, conditions)
+);
+
+create table set_subsets(
+  tenant text not null default '',
+  object text not null,
+  verb text not null,
+  subset_object text not null,
+  subset_verb text not null,
+  expires_at timestamptz,
+  conditions jsonb not null default '[]',
+
+  primary key(tenant, object, verb, subset_object, subset_verb, conditions)
 );
@@ -0,0 +1,526 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/td0m/doorman"
+	"github.com/td0m/doorman/db"
+	pb "github.com/td0m/doorman/gen/go"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Principal is who a request was made by.
+type Principal struct {
+	ID string
+	// api-key, token or certificate
+	Method string
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the principal the request was authenticated as, if authentication is enabled.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+func withPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// Credentials are what a request was sent with, either over grpc or through the gateway.
+type Credentials struct {
+	// from the x-api-key metadata or header
+	APIKey string
+	// from the authorization metadata or header, without the Bearer prefix
+	Token string
+	// the verified chain of the tls client certificate, leaf first
+	Certificates []*x509.Certificate
+}
+
+// Authenticator checks one kind of credentials.
+type Authenticator interface {
+	// Authenticate returns nil if the request was not sent with the credentials it checks,
+	// and fails if they were sent but are invalid.
+	Authenticate(ctx context.Context, creds Credentials) (*Principal, error)
+}
+
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// APIKeys authenticates static api keys, mapping each key to the principal it belongs to.
+type APIKeys map[string]string
+
+func (keys APIKeys) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	if creds.APIKey == "" {
+		return nil, nil
+	}
+
+	// Comparing with every key in constant time, so that the time taken doesn't tell how much of a key matched
+	var id string
+	for key, principal := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(creds.APIKey)) == 1 {
+			id = principal
+		}
+	}
+	if id == "" {
+		return nil, errInvalidCredentials
+	}
+
+	return &Principal{ID: id, Method: "api-key"}, nil
+}
+
+// HMACTokens authenticates JWTs signed with HS256, the subject being the principal. They must expire.
+// The tokens can be signed with any JWT library, or with Sign.
+type HMACTokens struct {
+	Secret []byte
+}
+
+type tokenClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	// only set in the tokens the gateway passes the principals it authenticated on with
+	Method string `json:"doorman_method,omitempty"`
+}
+
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Sign returns a token for the principal that expires after ttl.
+func (t HMACTokens) Sign(principal string, ttl time.Duration) (string, error) {
+	return t.sign(tokenClaims{Subject: principal, ExpiresAt: time.Now().Add(ttl).Unix()})
+}
+
+func (t HMACTokens) sign(claims tokenClaims) (string, error) {
+	bs, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("json marshaling failed: %w", err)
+	}
+
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(bs)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(t.signature(unsigned)), nil
+}
+
+func (t HMACTokens) signature(unsigned string) []byte {
+	mac := hmac.New(sha256.New, t.Secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func (t HMACTokens) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	if creds.Token == "" {
+		return nil, nil
+	}
+
+	claims, err := t.verify(creds.Token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Principal{ID: claims.Subject, Method: "token"}, nil
+}
+
+func (t HMACTokens) verify(token string, now time.Time) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", errInvalidCredentials)
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token header", errInvalidCredentials)
+	}
+	var alg struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &alg); err != nil || alg.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: only HS256 tokens are supported", errInvalidCredentials)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, t.signature(parts[0]+"."+parts[1])) {
+		return nil, fmt.Errorf("%w: invalid token signature", errInvalidCredentials)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token claims", errInvalidCredentials)
+	}
+	claims := &tokenClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed token claims", errInvalidCredentials)
+	}
+
+	switch {
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: token has no subject", errInvalidCredentials)
+	// Otherwise a leaked token could never be revoked, short of changing the secret
+	case claims.ExpiresAt == 0:
+		return nil, fmt.Errorf("%w: token never expires", errInvalidCredentials)
+	case !now.Before(time.Unix(claims.ExpiresAt, 0)):
+		return nil, fmt.Errorf("%w: token has expired", errInvalidCredentials)
+	case claims.NotBefore != 0 && now.Before(time.Unix(claims.NotBefore, 0)):
+		return nil, fmt.Errorf("%w: token is not valid yet", errInvalidCredentials)
+	}
+
+	return claims, nil
+}
+
+// ClientCertificates authenticates tls client certificates, the common name being the principal.
+// The certificates are verified by the tls config of the server, e.g. against its ClientCAs.
+type ClientCertificates struct{}
+
+func (ClientCertificates) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	if len(creds.Certificates) == 0 {
+		return nil, nil
+	}
+
+	id := creds.Certificates[0].Subject.CommonName
+	if id == "" {
+		return nil, fmt.Errorf("%w: certificate has no common name", errInvalidCredentials)
+	}
+
+	return &Principal{ID: id, Method: "certificate"}, nil
+}
+
+// APIKeyMetadataKey is the metadata, or the header through the gateway, api keys are sent in.
+const APIKeyMetadataKey = "x-api-key"
+
+// the gateway passes the principals it authenticated on to the grpc server with a token signed by a key only it knows
+const gatewayTokenMetadataKey = "doorman-gateway-token"
+
+const gatewayTokenTTL = time.Minute
+
+// Auth authenticates every request with the first authenticator whose credentials it was sent with.
+type Auth struct {
+	authenticators []Authenticator
+	gateway        HMACTokens
+}
+
+func NewAuth(authenticators ...Authenticator) (*Auth, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generating the gateway secret failed: %w", err)
+	}
+
+	return &Auth{authenticators: authenticators, gateway: HMACTokens{Secret: secret}}, nil
+}
+
+func (a *Auth) authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	for _, authenticator := range a.authenticators {
+		p, err := authenticator.Authenticate(ctx, creds)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if p != nil {
+			return p, nil
+		}
+	}
+
+	return nil, status.Error(codes.Unauthenticated, "no valid credentials were sent")
+}
+
+// authenticateGRPC authenticates the request by its metadata and the tls connection it was made over.
+func (a *Auth) authenticateGRPC(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	// Only the gateway can sign these, the ones sent by anyone else never verify
+	for _, token := range md.Get(gatewayTokenMetadataKey) {
+		if claims, err := a.gateway.verify(token, time.Now()); err == nil {
+			return withPrincipal(ctx, &Principal{ID: claims.Subject, Method: claims.Method}), nil
+		}
+	}
+
+	creds := Credentials{}
+	if keys := md.Get(APIKeyMetadataKey); len(keys) > 0 {
+		creds.APIKey = keys[0]
+	}
+	if auth := md.Get("authorization"); len(auth) > 0 {
+		creds.Token = bearerToken(auth[0])
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			creds.Certificates = info.State.VerifiedChains[0]
+		}
+	}
+
+	p, err := a.authenticate(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	return withPrincipal(ctx, p), nil
+}
+
+func bearerToken(authorization string) string {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+	return token
+}
+
+func (a *Auth) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticateGRPC(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *Auth) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticateGRPC(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// Middleware authenticates the requests to the gateway by their headers and the tls connection they were made over.
+// Requests that fail are answered with 401.
+func (a *Auth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		creds := Credentials{
+			APIKey: r.Header.Get(APIKeyMetadataKey),
+			Token:  bearerToken(r.Header.Get("Authorization")),
+		}
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			creds.Certificates = r.TLS.VerifiedChains[0]
+		}
+
+		p, err := a.authenticate(r.Context(), creds)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": codes.Unauthenticated, "message": status.Convert(err).Message()})
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), p)))
+	})
+}
+
+// GatewayMetadata passes the principal authenticated by Middleware on to the grpc server, see runtime.WithMetadata.
+func (a *Auth) GatewayMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	token, err := a.gateway.sign(tokenClaims{Subject: p.ID, Method: p.Method, ExpiresAt: time.Now().Add(gatewayTokenTTL).Unix()})
+	if err != nil {
+		// The grpc server will refuse the request as unauthenticated
+		slog.Error("signing the gateway token failed", "err", err)
+		return nil
+	}
+
+	return metadata.Pairs(gatewayTokenMetadataKey, token)
+}
+
+// ManageVerb is the verb a principal needs on the object of a grant or revoke, and on its subject if it changes
+// what the subject can do, or on SystemObject, to make it once authorization is enabled. See authorizeTuple.
+// The other writes, such as upserting roles, need it on SystemObject.
+const ManageVerb = "manage"
+
+// SystemObject stands for doorman itself. Managing it in the default tenant allows managing every tenant.
+const SystemObject = doorman.Object("doorman:system")
+
+// AdminRole is the role Bootstrap grants the admins on SystemObject.
+const AdminRole = "doorman:admin"
+
+// Subject is the object the principal is checked as, user:<id> unless its id is an object already, e.g. service:ci.
+func (p *Principal) Subject() doorman.Object {
+	if strings.Contains(p.ID, ":") {
+		return doorman.Object(p.ID)
+	}
+	return doorman.Object("user:" + p.ID)
+}
+
+// EnableAuthorization makes the writes of every tenant check that the principal may make them, see ManageVerb.
+// Requires authentication to be enabled, the requests without a principal are refused.
+func (d *Doorman) EnableAuthorization() {
+	d.tenants.authorize = true
+}
+
+// authorize fails with PermissionDenied unless the principal can manage SystemObject, or every one of the objects.
+// It waits for the changes committed so far to be applied first, so that e.g. a revoked admin is refused right away.
+func (d *Doorman) authorize(ctx context.Context, objects ...doorman.Object) error {
+	if !d.tenants.authorize {
+		return nil
+	}
+
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authorization requires authentication")
+	}
+
+	manages := func(d *Doorman, obj doorman.Object) (bool, error) {
+		res, err := d.check(ctx, d.sets, &pb.CheckRequest{Subject: string(p.Subject()), Verb: ManageVerb, Object: string(obj)})
+		if err != nil {
+			return false, fmt.Errorf("checking %s failed: %w", obj, err)
+		}
+		return res.Success, nil
+	}
+
+	admins := []*Doorman{d}
+	if d.tenant != "" {
+		root, err := d.tenants.get(ctx, "")
+		if err != nil {
+			return err
+		}
+		admins = append(admins, root)
+	}
+	for _, tenant := range admins {
+		if err := tenant.waitUntilCaughtUp(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, tenant := range admins {
+		if ok, err := manages(tenant, SystemObject); ok || err != nil {
+			return err
+		}
+	}
+
+	if len(objects) == 0 {
+		return status.Errorf(codes.PermissionDenied, "%s can't %s %s", p.Subject(), ManageVerb, SystemObject)
+	}
+	for _, obj := range objects {
+		ok, err := manages(d, obj)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return status.Errorf(codes.PermissionDenied, "%s can't %s %s or %s", p.Subject(), ManageVerb, obj, SystemObject)
+		}
+	}
+	return nil
+}
+
+// authorizeTuple authorizes granting or revoking the tuple. Besides its object, the principal has to manage the subject
+// if the tuple changes what it can do: when it becomes a child of the object through a parent role,
+// or when its members get the role too, as it is of a nestable type or a subject set.
+func (d *Doorman) authorizeTuple(ctx context.Context, subject doorman.Object, roleID string, object doorman.Object) error {
+	if !d.tenants.authorize {
+		return nil
+	}
+
+	objects := []doorman.Object{object}
+
+	// Unknown roles are left to the validation of the request
+	role, err := d.roles.Retrieve(ctx, roleID)
+	if err != nil && err != db.ErrInvalidRole {
+		return fmt.Errorf("roles.Retrieve failed: %w", err)
+	}
+
+	affectsSubject := role != nil && role.Parent
+	if !affectsSubject {
+		nestable, err := nestableTypes(ctx, d.types)
+		if err != nil {
+			return err
+		}
+		affectsSubject = nestable[subject.Type()]
+	}
+
+	if obj, _, ok := subject.SplitSubjectSet(); ok {
+		objects = append(objects, obj)
+	} else if affectsSubject {
+		objects = append(objects, subject)
+	}
+
+	return d.authorize(ctx, objects...)
+}
+
+// Bootstrap grants the admins AdminRole on SystemObject in the default tenant, registering the types it needs.
+// It is safe to call on every start, the role is reset to only having ManageVerb,
+// and revoked from the ones that are no longer admins.
+func (d *Doorman) Bootstrap(ctx context.Context, admins []doorman.Object) error {
+	d, err := d.tenants.get(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	types := []doorman.Type{{ID: SystemObject.Type()}}
+	tuples := make([]doorman.Tuple, len(admins))
+	for i, admin := range admins {
+		if err := admin.Validate(); err != nil {
+			return fmt.Errorf("admin %q: %w", admin, err)
+		}
+		types = append(types, doorman.Type{ID: admin.Type()})
+		tuples[i] = doorman.Tuple{Subject: admin, Role: AdminRole, Object: SystemObject}
+	}
+
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, t := range types {
+		_, err := d.types.WithTx(tx).Retrieve(ctx, t.ID)
+		if err == db.ErrInvalidType {
+			err = d.upsertTypeWithTx(ctx, tx, t)
+		}
+		if err != nil {
+			return fmt.Errorf("registering type %s failed: %w", t.ID, err)
+		}
+	}
+
+	roles := []doorman.Role{{ID: AdminRole, Verbs: []doorman.Verb{ManageVerb}}}
+	if _, err := d.applyWithTx(ctx, tx, roles, tuples, false); err != nil {
+		return err
+	}
+
+	granted, err := d.tuples.WithTx(tx).ListTuplesForRole(ctx, AdminRole)
+	if err != nil {
+		return fmt.Errorf("tuples.ListTuplesForRole failed: %w", err)
+	}
+	for _, t := range granted {
+		if t.Object != SystemObject || slices.Contains(admins, t.Subject) {
+			continue
+		}
+		_, err := d.revokeWithTx(ctx, tx, &pb.RevokeRequest{Subject: string(t.Subject), Role: t.Role, Object: string(t.Object)})
+		if err != nil {
+			return fmt.Errorf("revoking %s failed: %w", t, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return nil
+}
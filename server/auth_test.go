@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// unary calls the interceptor with a handler that returns the principal it was called with.
+func unary(auth *Auth, ctx context.Context) (*Principal, error) {
+	res, err := auth.UnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		p, _ := PrincipalFromContext(ctx)
+		return p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Principal), nil
+}
+
+func withMetadata(kv ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+}
+
+func TestAuth(t *testing.T) {
+	tokens := HMACTokens{Secret: []byte("secret")}
+	auth, err := NewAuth(APIKeys{"key1": "alice"}, tokens, ClientCertificates{})
+	require.NoError(t, err)
+
+	validToken, err := tokens.Sign("bob", time.Hour)
+	require.NoError(t, err)
+	expiredToken, err := tokens.Sign("bob", -time.Hour)
+	require.NoError(t, err)
+	otherToken, err := HMACTokens{Secret: []byte("other")}.Sign("bob", time.Hour)
+	require.NoError(t, err)
+	neverExpiringToken, err := tokens.sign(tokenClaims{Subject: "bob"})
+	require.NoError(t, err)
+
+	certCtx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "carol"}}}},
+	}}})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected *Principal
+	}{
+		{"api key", withMetadata(APIKeyMetadataKey, "key1"), &Principal{ID: "alice", Method: "api-key"}},
+		{"invalid api key", withMetadata(APIKeyMetadataKey, "key2"), nil},
+		{"token", withMetadata("authorization", "Bearer "+validToken), &Principal{ID: "bob", Method: "token"}},
+		{"expired token", withMetadata("authorization", "Bearer "+expiredToken), nil},
+		{"token signed with another secret", withMetadata("authorization", "Bearer "+otherToken), nil},
+		{"token without an expiry", withMetadata("authorization", "Bearer "+neverExpiringToken), nil},
+		{"malformed token", withMetadata("authorization", "Bearer foo"), nil},
+		{"certificate", certCtx, &Principal{ID: "carol", Method: "certificate"}},
+		{"no credentials", context.Background(), nil},
+		{"gateway token signed by someone else", withMetadata(gatewayTokenMetadataKey, validToken), nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := unary(auth, tc.ctx)
+			if tc.expected == nil {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, p)
+		})
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		var principal *Principal
+		handler := func(srv any, stream grpc.ServerStream) error {
+			principal, _ = PrincipalFromContext(stream.Context())
+			return nil
+		}
+
+		err := auth.StreamInterceptor()(nil, watchStream{ctx: withMetadata(APIKeyMetadataKey, "key1")}, &grpc.StreamServerInfo{}, handler)
+		require.NoError(t, err)
+		assert.Equal(t, &Principal{ID: "alice", Method: "api-key"}, principal)
+
+		err = auth.StreamInterceptor()(nil, watchStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("gateway", func(t *testing.T) {
+		// The gateway passes the principal on, which the grpc server accepts without the original credentials
+		var md metadata.MD
+		handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			md = auth.GatewayMetadata(r.Context(), r)
+		}))
+
+		r := httptest.NewRequest(http.MethodPost, "/check", nil)
+		r.Header.Set("Authorization", "Bearer "+validToken)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		p, err := unary(auth, metadata.NewIncomingContext(context.Background(), md))
+		require.NoError(t, err)
+		assert.Equal(t, &Principal{ID: "bob", Method: "token"}, p)
+
+		// Another server doesn't accept it
+		other, err := NewAuth(tokens)
+		require.NoError(t, err)
+		_, err = unary(other, metadata.NewIncomingContext(context.Background(), md))
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		r = httptest.NewRequest(http.MethodPost, "/check", nil)
+		r.Header.Set(APIKeyMetadataKey, "key2")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"code": 16, "message": "invalid credentials"}`, w.Body.String())
+	})
+}
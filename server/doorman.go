@@ -3,36 +3,60 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/xid"
 	"github.com/td0m/doorman"
 	"github.com/td0m/doorman/db"
 	pb "github.com/td0m/doorman/gen/go"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// Doorman serves a single tenant. Any of them can receive an RPC, it is served by the one of the tenant of the request,
+// see forTenant.
 type Doorman struct {
 	*pb.UnimplementedDoormanServer
 
-	conn *pgxpool.Pool
+	tenant  string
+	tenants *tenants
+
+	// scoped to the tenant, as are the stores below
+	store db.Store
 
 	processing chan bool
 
+	applied   *notifier
+	committed *notifier
+	// the changes applied to the cache by this instance, as opposed to the others
+	local *appliedChanges
+
 	sets    db.Sets
-	changes db.Changes
+	changes db.ChangeStore
 	objects db.Objects
-	roles   db.Roles
-	tuples  db.Tuples
+	roles   db.RoleStore
+	tuples  db.TupleStore
+	types   db.TypeStore
 }
 
 func (d *Doorman) ProcessAllChanges() error {
 	for {
 		if err := d.ProcessChange(); err != nil {
-			if err == pgx.ErrNoRows {
+			if err == db.ErrNoChanges {
 				return nil
 			}
 			return fmt.Errorf("processing change failed: %w", err)
@@ -41,302 +65,2173 @@ func (d *Doorman) ProcessAllChanges() error {
 }
 
 func (d *Doorman) Changes(ctx context.Context, request *pb.ChangesRequest) (*pb.ChangesResponse, error) {
-	changes, err := d.changes.List(ctx, db.ChangeFilter{PaginationToken: request.PaginationToken})
+	d, err := d.forTenant(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("db failed: %w", err)
+		return nil, err
 	}
-	res := &pb.ChangesResponse{
-		Items: make([]*pb.Change, len(changes)),
+
+	filter := db.ChangeFilter{
+		Type:   request.Type,
+		Status: request.Status,
 	}
-	for i, v := range changes {
-		res.Items[i] = mapChangeToPb(v)
-		if i == len(changes)-1 {
-			res.PaginationToken = &v.ID
-		}
+	if request.CreatedAfter != nil {
+		t := request.CreatedAfter.AsTime()
+		filter.CreatedAfter = &t
 	}
-	return res, nil
-}
-
-func (d *Doorman) Check(ctx context.Context, request *pb.CheckRequest) (*pb.CheckResponse, error) {
-	success, err := d.sets.Contains(ctx, doorman.Set{
-		Object: doorman.Object(request.Object),
-		Verb:   doorman.Verb(request.Verb),
-	}, doorman.Object(request.Subject))
-	if err != nil {
-		return &pb.CheckResponse{}, fmt.Errorf("check failed: %w", err)
+	if request.CreatedBefore != nil {
+		t := request.CreatedBefore.AsTime()
+		filter.CreatedBefore = &t
 	}
-	return &pb.CheckResponse{Success: success}, nil
-}
 
-func (d *Doorman) Grant(ctx context.Context, request *pb.GrantRequest) (*pb.GrantResponse, error) {
-	tx, err := d.conn.Begin(ctx)
+	items, next, err := d.listChanges(ctx, filter, request.PaginationToken, request.PageSize)
 	if err != nil {
-		return nil, fmt.Errorf("begin tx failed: %w", err)
+		return nil, err
 	}
 
-	res, err := d.grantWithTx(ctx, tx, request)
+	return &pb.ChangesResponse{Items: items, PaginationToken: next}, nil
+}
+
+func (d *Doorman) Audit(ctx context.Context, request *pb.AuditRequest) (*pb.AuditResponse, error) {
+	d, err := d.forTenant(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	filter := db.ChangeFilter{
+		Actor:   request.Actor,
+		Subject: request.Subject,
+		Object:  request.Object,
+	}
+	if request.CreatedAfter != nil {
+		t := request.CreatedAfter.AsTime()
+		filter.CreatedAfter = &t
+	}
+	if request.CreatedBefore != nil {
+		t := request.CreatedBefore.AsTime()
+		filter.CreatedBefore = &t
 	}
 
-	d.processChangesImmediately()
-
-	return res, nil
-}
+	items, next, err := d.listChanges(ctx, filter, request.PaginationToken, request.PageSize)
+	if err != nil {
+		return nil, err
+	}
 
-func (d *Doorman) processChangesImmediately() {
-	go func() {
-		d.processing <- true
-	}()
+	return &pb.AuditResponse{Items: items, PaginationToken: next}, nil
 }
 
-func (d *Doorman) ListObjects(ctx context.Context, request *pb.ListObjectsRequest) (*pb.ListObjectsResponse, error) {
-	sub := doorman.Object(request.Subject)
-	parents, err := d.sets.ListParents(ctx, sub)
+// listChanges lists a page of the changes, and the pagination token of the next one if there are more.
+func (d *Doorman) listChanges(ctx context.Context, filter db.ChangeFilter, paginationToken *string, requestedPageSize *int32) ([]*pb.Change, *string, error) {
+	limit, err := pageSize(requestedPageSize)
 	if err != nil {
-		return nil, fmt.Errorf("sets.ListParents failed: %w", err)
+		return nil, nil, err
 	}
 
-	items := make([]*pb.Relation, len(parents))
-	for i, parent := range parents {
-		items[i] = &pb.Relation{
-			Subject: string(sub),
-			Verb:    string(parent.Verb),
-			Object:  string(parent.Object),
+	// The token is the seq of the last change on the previous page
+	if paginationToken != nil {
+		after, err := strconv.ParseInt(*paginationToken, 10, 64)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "invalid pagination token: %s", *paginationToken)
 		}
+		filter.After = &after
 	}
 
-	return &pb.ListObjectsResponse{
-		Items: items,
-	}, nil
-}
+	// One more than requested, to know if there is a next page
+	limitWithNext := limit + 1
+	filter.Limit = &limitWithNext
 
-func (d *Doorman) ListRoles(ctx context.Context, request *pb.ListRolesRequest) (*pb.ListRolesResponse, error) {
-	roles, err := d.roles.List(ctx)
+	changes, err := d.changes.List(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("list failed: %w", err)
+		return nil, nil, fmt.Errorf("db failed: %w", err)
 	}
 
-	items := make([]*pb.Role, len(roles))
-	for i, r := range roles {
-		items[i] = mapRoleToPb(r)
+	var next *string
+	if len(changes) > limit {
+		changes = changes[:limit]
+		token := strconv.FormatInt(changes[limit-1].Seq, 10)
+		next = &token
 	}
 
-	return &pb.ListRolesResponse{
-		Items: items,
-	}, nil
+	items := make([]*pb.Change, len(changes))
+	for i, c := range changes {
+		items[i], err = mapChangeToPb(c)
+		if err != nil {
+			return nil, nil, fmt.Errorf("mapping change %s failed: %w", c.ID, err)
+		}
+	}
+
+	return items, next, nil
 }
 
-func (d *Doorman) ProcessChange() error {
-	timeout := time.Second * 5
-	stalePeriod := time.Hour
+// At most this many items can be checked in a single BatchCheck
+const maxBatchCheckItems = 1000
 
-	// This timeout should be higher than the "timeout", otherwise the tx.Commit will fail
-	ctx, cancel := context.WithTimeout(context.Background(), timeout+time.Second*2)
-	defer cancel()
+const watchPollInterval = time.Second
 
-	tx, err := d.conn.Begin(ctx)
+// Watch streams the changes as they are committed. Changes committed by other instances are polled for.
+func (d *Doorman) Watch(request *pb.WatchRequest, stream pb.Doorman_WatchServer) error {
+	ctx := stream.Context()
+	d, err := d.forTenant(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin a tx: %w", err)
+		return err
 	}
 
-	var c doorman.Change
-	err = tx.QueryRow(ctx, `
-		update changes
-		set status='processed'
-		where id in
-		(
-		  select id
-		  from changes
-			where status='pending'
-		  order by random()
-		  for update skip locked
-		  limit 1
-		)
-		returning id, type, payload, created_at
-	`).Scan(&c.ID, &c.Type, &c.Payload, &c.CreatedAt)
+	// Resuming from the seq rather than the id, as ids are only sortable by the time they were created
+	var after int64
+	if request.After != nil {
+		change, err := d.changes.Retrieve(ctx, *request.After)
+		if err == db.ErrInvalidChange {
+			return status.Errorf(codes.InvalidArgument, "invalid change: %s", *request.After)
+		}
+		if err != nil {
+			return fmt.Errorf("changes.Retrieve failed: %w", err)
+		}
+		after = change.Seq
+	} else {
+		after, err = d.changes.LastSeq(ctx)
+		if err != nil {
+			return fmt.Errorf("changes.LastSeq failed: %w", err)
+		}
+	}
 
-	// No rows = no tasks
-	if err == pgx.ErrNoRows {
-		slog.Debug("no tasks, sleeping")
+	limit := maxPageSize
+	for {
+		committed := d.committed.wait()
 
-		select {
-		case <-d.processing:
-		case <-time.After(timeout):
+		changes, err := d.changes.List(ctx, db.ChangeFilter{After: &after, Limit: &limit})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("changes.List failed: %w", err)
 		}
 
-		if err := tx.Commit(ctx); err != nil {
-			return fmt.Errorf("tx failed to commit: %w", err)
+		for _, c := range changes {
+			res, err := mapChangeToPb(c)
+			if err != nil {
+				return fmt.Errorf("mapping change %s failed: %w", c.ID, err)
+			}
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+			after = c.Seq
+		}
+
+		// Not waiting if there is more to catch up on
+		if len(changes) == limit {
+			continue
+		}
+
+		select {
+		case <-committed:
+		case <-time.After(watchPollInterval):
+		case <-ctx.Done():
+			return nil
 		}
-		return pgx.ErrNoRows
 	}
+}
 
-	// Failed to execute query, probably a bad query/schema
+func (d *Doorman) BatchCheck(ctx context.Context, request *pb.BatchCheckRequest) (*pb.BatchCheckResponse, error) {
+	d, err := d.forTenant(ctx)
 	if err != nil {
-		if err := tx.Rollback(ctx); err != nil {
-			return fmt.Errorf("failed to rollback: %w", err)
-		}
-		return fmt.Errorf("failed to query/scan: %w", err)
+		return nil, err
 	}
 
-	// Process task
-	if err := d.processChange(ctx, c); err != nil {
-		if err := tx.Rollback(ctx); err != nil {
-			return fmt.Errorf("failed to rollback: %w", err)
-		}
+	if len(request.Items) > maxBatchCheckItems {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d items can be checked at once", maxBatchCheckItems)
+	}
 
-		time.Sleep(timeout)
+	items := make([]*pb.BatchCheckResult, len(request.Items))
 
-		// Tasks older than stalePeriod get logged
-		if time.Since(c.CreatedAt) > stalePeriod {
-			// TODO: probably log this somewhere else
-			slog.Info("stale change", "change", c)
+	types := newTypeResolver(d.types)
+
+	// Validating and waiting before taking the view, as it blocks the changes from being applied
+	waited := map[string]error{}
+	for i, item := range request.Items {
+		if err := types.Validate(ctx, doorman.Object(item.Subject), doorman.Object(item.Object)); err != nil {
+			items[i] = &pb.BatchCheckResult{Result: &pb.BatchCheckResult_Error{Error: status.Convert(err).Proto()}}
+			continue
 		}
 
-		return fmt.Errorf("failed to process change %s: %w", c.Type, err)
-	}
+		if item.ConsistencyToken == nil {
+			continue
+		}
 
-	// No errors, so task can be deleted
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("tx failed to commit: %w", err)
+		err, ok := waited[*item.ConsistencyToken]
+		if !ok {
+			err = d.waitUntilApplied(ctx, item.ConsistencyToken)
+			waited[*item.ConsistencyToken] = err
+		}
+		if err != nil {
+			items[i] = &pb.BatchCheckResult{Result: &pb.BatchCheckResult_Error{Error: status.Convert(err).Proto()}}
+		}
 	}
-	return nil
-}
 
-func (d *Doorman) RebuildCache(ctx context.Context, request *pb.RebuildCacheRequest) (*pb.RebuildCacheResponse, error) {
-	if err := d.changes.SetStatusOfAll(ctx, "pending"); err != nil {
-		return nil, fmt.Errorf("marking all as pending failed: %w", err)
+	// Checking all items against the same view, so that the results are consistent with each other
+	err = d.sets.View(func(sets db.Sets) error {
+		for i, item := range request.Items {
+			if items[i] != nil {
+				continue
+			}
+
+			res, err := d.check(ctx, sets, item)
+			if err != nil {
+				items[i] = &pb.BatchCheckResult{Result: &pb.BatchCheckResult_Error{Error: status.Convert(err).Proto()}}
+				continue
+			}
+			items[i] = &pb.BatchCheckResult{Result: &pb.BatchCheckResult_Response{Response: res}}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sets.View failed: %w", err)
 	}
-	return &pb.RebuildCacheResponse{}, nil
+
+	return &pb.BatchCheckResponse{Items: items}, nil
 }
 
-func (d *Doorman) RemoveRole(ctx context.Context, request *pb.RemoveRoleRequest) (*pb.Role, error) {
-	role, err := d.roles.Retrieve(ctx, request.Id)
+func (d *Doorman) Check(ctx context.Context, request *pb.CheckRequest) (*pb.CheckResponse, error) {
+	d, err := d.forTenant(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("db.Retrieve failed: %w", err)
+		return nil, err
 	}
 
-	var tuples []doorman.Tuple
-	tuples, err = d.tuples.ListTuplesForRole(ctx, role.ID)
-	if err != nil {
-		return nil, fmt.Errorf("ListTuplesForRole failed: %w", err)
+	types := newTypeResolver(d.types)
+	if err := types.Validate(ctx, doorman.Object(request.Subject), doorman.Object(request.Object)); err != nil {
+		return nil, err
+	}
+
+	if err := d.waitUntilApplied(ctx, request.ConsistencyToken); err != nil {
+		return nil, err
 	}
 
-	tx, err := d.conn.Begin(ctx)
+	return d.check(ctx, d.sets, request)
+}
+
+func (d *Doorman) check(ctx context.Context, sets db.Sets, request *pb.CheckRequest) (*pb.CheckResponse, error) {
+	success, conditions, err := sets.Contains(ctx, doorman.Set{
+		Object: doorman.Object(request.Object),
+		Verb:   doorman.Verb(request.Verb),
+	}, doorman.Object(request.Subject))
 	if err != nil {
-		return nil, fmt.Errorf("begin tx failed: %w", err)
+		return &pb.CheckResponse{}, fmt.Errorf("check failed: %w", err)
 	}
 
-	for _, t := range tuples {
-		_, err := d.revokeWithTx(ctx, tx, &pb.RevokeRequest{
-			Subject: string(t.Subject),
-			Role:    role.ID,
-			Object:  string(t.Object),
-		})
+	res := &pb.CheckResponse{Success: success, Decision: pb.Decision_ALLOWED}
+	checkContext := mapContextFromPb(request.Context)
+	if !success {
+		res.Decision, res.MissingContext, err = evaluateConditions(conditions, checkContext)
 		if err != nil {
-			return nil, fmt.Errorf("revoke failed for %s: %w, %w", t, err, tx.Rollback(ctx))
+			return nil, err
 		}
+		res.Success = res.Decision == pb.Decision_ALLOWED
 	}
 
-	if err := d.roles.WithTx(tx).Remove(ctx, request.Id); err != nil {
-		return nil, fmt.Errorf("update failed: %w, %w", err, tx.Rollback(ctx))
+	if !res.Success || !request.Explain {
+		return res, nil
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	path, role, err := d.explain(ctx, doorman.Object(request.Subject), doorman.Verb(request.Verb), doorman.Object(request.Object), checkContext)
+	if err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+	if role != nil {
+		res.Path = mapPathToPb(path)
+		res.Role = mapRoleToPb(*role)
 	}
 
-	return &pb.Role{}, nil
+	return res, nil
 }
 
-func (d *Doorman) Revoke(ctx context.Context, request *pb.RevokeRequest) (*pb.RevokeResponse, error) {
-	tx, err := d.conn.Begin(ctx)
+// CheckAt replays the changes made until the instant into an empty store, and checks against it.
+// The types are replayed too, from the ones the store is created with.
+func (d *Doorman) CheckAt(ctx context.Context, request *pb.CheckAtRequest) (*pb.CheckResponse, error) {
+	d, err := d.forTenant(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("begin tx failed: %w", err)
+		return nil, err
 	}
 
-	res, err := d.revokeWithTx(ctx, tx, request)
+	if request.At == nil {
+		return nil, status.Error(codes.InvalidArgument, "at is required")
+	}
+	at := request.At.AsTime()
+	if at.After(time.Now()) {
+		return nil, status.Error(codes.InvalidArgument, "at must not be in the future")
+	}
+	if err := doorman.Object(request.Subject).Validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%q: %s", request.Subject, err)
+	}
+	if err := doorman.Object(request.Object).Validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%q: %s", request.Object, err)
+	}
+
+	past, err := d.replay(ctx, at)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("tx.Commit failed: %w", err)
-	}
+	return past.check(ctx, past.sets, &pb.CheckRequest{
+		Subject: request.Subject,
+		Verb:    request.Verb,
+		Object:  request.Object,
+		Explain: true,
+		Context: request.Context,
+	})
+}
 
-	d.processChangesImmediately()
+// At most this many changes are replayed by CheckAt, as it builds the state from scratch on every request
+const maxReplayedChanges = 100_000
+
+// replay returns a Doorman backed by memory, with the types, roles and tuples as they were at the instant.
+// The tuples that had expired by then are left out, and the rest don't expire, as they are checked as of now.
+// Fails with FailedPrecondition if a tuple uses a role or type that was added before such changes were recorded.
+func (d *Doorman) replay(ctx context.Context, at time.Time) (*Doorman, error) {
+	// Inclusive of the instant, to the millisecond as that's as precisely as every store keeps the time
+	before := at.Truncate(time.Millisecond).Add(time.Millisecond)
+	limit := maxReplayedChanges + 1
+	changes, err := d.changes.List(ctx, db.ChangeFilter{CreatedBefore: &before, Limit: &limit})
+	if err != nil {
+		return nil, fmt.Errorf("changes.List failed: %w", err)
+	}
+	if len(changes) > maxReplayedChanges {
+		return nil, status.Errorf(codes.ResourceExhausted, "more than %d changes to replay until %s", maxReplayedChanges, at.Format(time.RFC3339))
+	}
 
-	return res, nil
-}
+	// The store registers the default types without a change
+	types := map[string]doorman.Type{}
+	if d.tenant == "" {
+		for _, t := range db.DefaultTypes {
+			types[t.ID] = t
+		}
+	}
+	roles := map[string]doorman.Role{}
+	tuples := map[string]doorman.Tuple{}
+	for _, c := range changes {
+		switch c.Type {
+		case "TYPE_UPSERTED", "TYPE_REMOVED":
+			var typ doorman.Type
+			if err := json.Unmarshal(c.Payload, &typ); err != nil {
+				return nil, fmt.Errorf("json unmarshal of change %s failed: %w", c.ID, err)
+			}
+			if c.Type == "TYPE_UPSERTED" {
+				types[typ.ID] = typ
+			} else {
+				delete(types, typ.ID)
+			}
+		case "ROLE_UPSERTED", "ROLE_REMOVED":
+			var role doorman.Role
+			if err := json.Unmarshal(c.Payload, &role); err != nil {
+				return nil, fmt.Errorf("json unmarshal of change %s failed: %w", c.ID, err)
+			}
+			if c.Type == "ROLE_UPSERTED" {
+				roles[role.ID] = role
+			} else {
+				delete(roles, role.ID)
+			}
+		case "GRANTED", "REVOKED":
+			var tuple doorman.Tuple
+			if err := json.Unmarshal(c.Payload, &tuple); err != nil {
+				return nil, fmt.Errorf("json unmarshal of change %s failed: %w", c.ID, err)
+			}
+			if c.Type == "GRANTED" {
+				tuples[tuple.String()] = tuple
+			} else {
+				delete(tuples, tuple.String())
+			}
+		}
+	}
 
-func (d *Doorman) UpsertRole(ctx context.Context, request *pb.UpsertRoleRequest) (*pb.Role, error) {
-	role, err := d.roles.Retrieve(ctx, request.Id)
-	if err == db.ErrInvalidRole {
-		role = &doorman.Role{ID: request.Id}
-	} else if err != nil {
-		return nil, fmt.Errorf("db.Retrieve failed: %w", err)
+	incomplete := func(kind, id string) error {
+		return status.Errorf(codes.FailedPrecondition, "the changes until %s are incomplete, %s %q was added before they were recorded", at.Format(time.RFC3339), kind, id)
+	}
+	for _, r := range roles {
+		for _, included := range r.Includes {
+			if _, ok := roles[included]; !ok {
+				return nil, incomplete("role", included)
+			}
+		}
 	}
 
-	tuples, err := d.tuples.ListTuplesForRole(ctx, role.ID)
-	if err != nil {
-		return nil, fmt.Errorf("ListTuplesForRole failed: %w", err)
+	var pastTuples []doorman.Tuple
+	for _, t := range tuples {
+		if t.Expired(at) {
+			continue
+		}
+		t.ExpiresAt = nil
+		pastTuples = append(pastTuples, t)
+
+		if _, ok := roles[t.Role]; !ok {
+			return nil, incomplete("role", t.Role)
+		}
+		for _, obj := range []doorman.Object{t.Subject, t.Object} {
+			if _, ok := types[obj.Type()]; !ok {
+				return nil, incomplete("type", obj.Type())
+			}
+		}
 	}
 
-	// THIS SHOULD BE A TX. IF IT FAILS AT ANY POINT AFTER REVOKING AT LEAST 1 THEN WE ARE F*CKED
-	// BECAUSE WE HAVE LOST SOURCE OF TRUF
-	tx, err := d.conn.Begin(ctx)
+	// Replacing rather than merging, as the default types of the store may have been changed since
+	past := NewDoorman(db.NewMemory())
+	tx, err := past.store.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("begin tx failed: %w", err)
 	}
+	defer tx.Rollback(ctx)
+	if _, err := past.importWithTx(ctx, tx, pb.ImportMode_REPLACE, maps.Values(types), maps.Values(roles), pastTuples); err != nil {
+		return nil, fmt.Errorf("replaying changes failed: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	}
 
-	for _, t := range tuples {
-		_, err := d.Revoke(ctx, &pb.RevokeRequest{
-			Subject: string(t.Subject),
-			Role:    role.ID,
-			Object:  string(t.Object),
-		})
+	for {
+		err := past.processPendingChange()
+		if err == db.ErrNoChanges {
+			return past, nil
+		}
 		if err != nil {
-			return nil, fmt.Errorf("revoke failed for %s: %w, %w", t, err, tx.Rollback(ctx))
+			return nil, fmt.Errorf("processing replayed changes failed: %w", err)
 		}
 	}
+}
 
-	role.Verbs = []doorman.Verb{}
-	for _, v := range request.Verbs {
-		role.Verbs = append(role.Verbs, doorman.Verb(v))
+// evaluateConditions allows if all conditions of any of the alternatives hold.
+// Otherwise, the context is missing fields if they could change that.
+func evaluateConditions(alternatives [][]string, checkContext map[string]any) (pb.Decision, []string, error) {
+	missing := []string{}
+	for _, conditions := range alternatives {
+		hold, missingFields, err := conditionsHold(conditions, checkContext)
+		if err != nil {
+			return pb.Decision_DENIED, nil, err
+		}
+		if hold {
+			return pb.Decision_ALLOWED, nil, nil
+		}
+		missing = append(missing, missingFields...)
 	}
 
-	// If this fails all grant requests will fail...
-	if err := d.roles.Upsert(ctx, role); err != nil {
-		return nil, fmt.Errorf("update failed: %w, %w", err, tx.Rollback(ctx))
+	if len(missing) == 0 {
+		return pb.Decision_DENIED, nil, nil
 	}
+	slices.Sort(missing)
+	return pb.Decision_MISSING_CONTEXT, slices.Compact(missing), nil
+}
 
-	for _, t := range tuples {
-		_, err := d.grantWithTx(ctx, tx, &pb.GrantRequest{
-			Subject: string(t.Subject),
-			Role:    role.ID,
-			Object:  string(t.Object),
-		})
+// conditionsHold only returns the missing fields if none of the conditions are known to be false.
+func conditionsHold(conditions []string, checkContext map[string]any) (bool, []string, error) {
+	missing := []string{}
+	for _, src := range conditions {
+		condition, err := doorman.ParseCondition(src)
+		if err != nil {
+			return false, nil, fmt.Errorf("parsing stored condition failed: %w", err)
+		}
+		hold, missingFields, err := condition.Evaluate(checkContext)
 		if err != nil {
-			return nil, fmt.Errorf("grant failed: %w, %w", err, tx.Rollback(ctx))
+			return false, nil, status.Errorf(codes.InvalidArgument, "evaluating %q failed: %s", src, err)
+		}
+		if len(missingFields) > 0 {
+			missing = append(missing, missingFields...)
+			continue
+		}
+		if !hold {
+			return false, nil, nil
 		}
 	}
+	return len(missing) == 0, missing, nil
+}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+// explain finds the shortest path that grants subject the verb on obj, and the role that supplied it.
+// Every object in between has to be nestable, e.g. a group the subject inherits from, or lead to a subject set of it,
+// and the conditions on the way have to hold.
+// If the access is through the wildcard of the subject's type, the path starts at the wildcard with an empty role.
+func (d *Doorman) explain(ctx context.Context, subject doorman.Object, verb doorman.Verb, obj doorman.Object, checkContext map[string]any) (doorman.Path, *doorman.Role, error) {
+	roles := newRoleResolver(d.roles)
+	nestable, err := nestableTypes(ctx, d.types)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	d.processChangesImmediately()
+	now := time.Now()
+	targets, err := d.ancestors(ctx, roles, obj, verb, func(link doorman.Tuple) bool {
+		if link.Expired(now) {
+			return false
+		}
+		if link.Condition == "" {
+			return true
+		}
+		hold, _, err := conditionsHold([]string{link.Condition}, checkContext)
+		return err == nil && hold
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return mapRoleToPb(*role), nil
+	path, role, err := d.explainFrom(ctx, roles, nestable, subject, verb, targets, checkContext)
+	if err != nil || role != nil || subject.IsWildcard() {
+		return path, role, err
+	}
+
+	path, role, err = d.explainFrom(ctx, roles, nestable, subject.Wildcard(), verb, targets, checkContext)
+	if role == nil {
+		return nil, nil, err
+	}
+	return append(doorman.Path{{Object: subject.Wildcard()}}, path...), role, nil
+}
+
+// ancestor is an object a verb flows from to a descendant, through the parent roles on the path down to it.
+type ancestor struct {
+	Object doorman.Object
+	Path   doorman.Path
+}
+
+// ancestors lists obj and the objects the verb flows to it from, nearest first.
+// Only the tuples with a parent role that follow returns true for are followed, e.g. the ones that haven't expired.
+func (d *Doorman) ancestors(ctx context.Context, roles roleResolver, obj doorman.Object, verb doorman.Verb, follow func(link doorman.Tuple) bool) ([]ancestor, error) {
+	ancestors := []ancestor{{Object: obj}}
+	for i := 0; i < len(ancestors); i++ {
+		links, err := d.tuples.ListParents(ctx, ancestors[i].Object)
+		if err != nil {
+			return nil, fmt.Errorf("db.ListParents failed: %w", err)
+		}
+
+		for _, link := range links {
+			if slices.ContainsFunc(ancestors, func(a ancestor) bool { return a.Object == link.Object }) || !follow(link) {
+				continue
+			}
+			flows, err := roles.Flows(ctx, link.Role, verb)
+			if err != nil {
+				return nil, err
+			}
+			if !flows {
+				continue
+			}
+
+			conn := doorman.Connection{Role: link.Role, Object: link.Subject, ExpiresAt: link.ExpiresAt, Condition: link.Condition}
+			ancestors = append(ancestors, ancestor{
+				Object: link.Object,
+				Path:   append(doorman.Path{conn}, ancestors[i].Path...),
+			})
+		}
+	}
+	return ancestors, nil
+}
+
+// explainFrom tries the targets in order, the path continues from the target to the object it is an ancestor of.
+func (d *Doorman) explainFrom(ctx context.Context, roles roleResolver, nestable map[string]bool, subject doorman.Object, verb doorman.Verb, targets []ancestor, checkContext map[string]any) (doorman.Path, *doorman.Role, error) {
+	paths, err := d.tuples.ListConnected(ctx, subject, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listConnected failed: %w", err)
+	}
+
+	now := time.Now()
+
+	for _, target := range targets {
+		path, role, err := explainTarget(ctx, roles, nestable, paths, verb, target.Object, checkContext, now)
+		if err != nil || role != nil {
+			return append(path, target.Path...), role, err
+		}
+	}
+
+	return nil, nil, nil
+}
+
+func explainTarget(ctx context.Context, roles roleResolver, nestable map[string]bool, paths []doorman.Path, verb doorman.Verb, obj doorman.Object, checkContext map[string]any, now time.Time) (doorman.Path, *doorman.Role, error) {
+	for _, path := range paths {
+		if path.Object() != obj || path.Expired(now) {
+			continue
+		}
+		if hold, _, err := conditionsHold(path.Conditions(), checkContext); err != nil || !hold {
+			continue
+		}
+
+		connects, err := roles.Connects(ctx, nestable, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !connects {
+			continue
+		}
+
+		last := path[len(path)-1]
+		granted, err := roles.HasVerb(ctx, last.Role, verb)
+		if err != nil {
+			return nil, nil, err
+		}
+		if granted {
+			return path, roles.cache[last.Role], nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+func (d *Doorman) Grant(ctx context.Context, request *pb.GrantRequest) (*pb.GrantResponse, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.authorizeTuple(ctx, doorman.Object(request.Subject), request.Role, doorman.Object(request.Object)); err != nil {
+		return nil, err
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.AsTime().After(time.Now()) {
+		return nil, status.Error(codes.InvalidArgument, "expires_at must be in the future")
+	}
+	if err := validateGrant(ctx, newTypeResolver(d.types), d.roles, request); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	res, err := d.grantWithTx(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return res, nil
+}
+
+// validateGrant checks everything about a grant but the role and whether it expires, those are checked when it is added.
+func validateGrant(ctx context.Context, types typeResolver, roles db.RoleStore, request *pb.GrantRequest) error {
+	if err := types.Validate(ctx, doorman.Object(request.Subject), doorman.Object(request.Object)); err != nil {
+		return err
+	}
+	if len(request.Condition) > doorman.MaxConditionLength {
+		return status.Errorf(codes.InvalidArgument, "condition is longer than %d characters", doorman.MaxConditionLength)
+	}
+	if request.Condition != "" {
+		if _, err := doorman.ParseCondition(request.Condition); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if doorman.Object(request.Object).IsWildcard() {
+		return status.Error(codes.InvalidArgument, "only subjects can be wildcards")
+	}
+	if doorman.Object(request.Object).IsSubjectSet() {
+		return status.Error(codes.InvalidArgument, "only subjects can be subject sets")
+	}
+	if _, roleID, ok := doorman.Object(request.Subject).SplitSubjectSet(); ok {
+		role, err := roles.Retrieve(ctx, roleID)
+		if err == db.ErrInvalidRole {
+			return status.Errorf(codes.InvalidArgument, "subject set %q: unknown role %q", request.Subject, roleID)
+		}
+		if err != nil {
+			return fmt.Errorf("roles.Retrieve failed: %w", err)
+		}
+		if role.Deny || role.Parent {
+			return status.Errorf(codes.InvalidArgument, "subject set %q: deny and parent roles have no subject set", request.Subject)
+		}
+	}
+
+	return nil
+}
+
+func (d *Doorman) changesCommitted() {
+	d.committed.notify()
+	d.processChangesImmediately()
+}
+
+func (d *Doorman) processChangesImmediately() {
+	go func() {
+		d.processing <- true
+	}()
+}
+
+func (d *Doorman) ListObjects(ctx context.Context, request *pb.ListObjectsRequest) (*pb.ListObjectsResponse, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.waitUntilApplied(ctx, request.ConsistencyToken); err != nil {
+		return nil, err
+	}
+
+	sub := doorman.Object(request.Subject)
+	parents, err := d.sets.ListParents(ctx, sub)
+	if err != nil {
+		return nil, fmt.Errorf("sets.ListParents failed: %w", err)
+	}
+
+	items := make([]*pb.Relation, 0, len(parents))
+	for _, parent := range parents {
+		// Being in a subject set is not a verb on an object
+		if parent.Object.IsSubjectSet() {
+			continue
+		}
+		items = append(items, &pb.Relation{
+			Subject: string(sub),
+			Verb:    string(parent.Verb),
+			Object:  string(parent.Object),
+		})
+	}
+
+	return &pb.ListObjectsResponse{
+		Items: items,
+	}, nil
+}
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+func pageSize(requested *int32) (int, error) {
+	if requested == nil {
+		return defaultPageSize, nil
+	}
+	if *requested <= 0 || *requested > maxPageSize {
+		return 0, status.Errorf(codes.InvalidArgument, "page size must be between 1 and %d", maxPageSize)
+	}
+	return int(*requested), nil
+}
+
+// ListSubjects lists who can perform the verb on the object, sorted by subject.
+// Same as in Check, members of a group only get its verbs if their role on the group inherits,
+// and the subjects of the object's parents get the verbs that flow from them.
+func (d *Doorman) ListSubjects(ctx context.Context, request *pb.ListSubjectsRequest) (*pb.ListSubjectsResponse, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := doorman.Object(request.Object)
+	verb := doorman.Verb(request.Verb)
+
+	limit, err := pageSize(request.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.waitUntilApplied(ctx, request.ConsistencyToken); err != nil {
+		return nil, err
+	}
+
+	var after doorman.Object
+	if request.PaginationToken != nil {
+		after = doorman.Object(*request.PaginationToken)
+	}
+
+	roles := newRoleResolver(d.roles)
+	now := time.Now()
+
+	// The subjects of the objects the verb flows to obj from have it too
+	ancestors, err := d.ancestors(ctx, roles, obj, verb, func(link doorman.Tuple) bool {
+		return !link.Expired(now) && link.Condition == ""
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the subjects after the page token are listed, so that the pages don't depend on the ones before them.
+	// More than a page of them, as some turn out to be denied or not to have the verb.
+	fetched := limit * 2
+	var paths []subjectPath
+	// The subjects after it may have paths from the ancestors that were cut off, so they are left to the next page
+	var cutoff *doorman.Object
+	for _, a := range ancestors {
+		connected, err := d.tuples.ListSubjectPaths(ctx, a.Object, after, fetched)
+		if err != nil {
+			return nil, fmt.Errorf("listSubjectPaths failed: %w", err)
+		}
+
+		listed := map[doorman.Object]bool{}
+		for _, path := range connected {
+			if sub := path.Object(); sub > after {
+				listed[sub] = true
+			}
+			paths = append(paths, subjectPath{Subject: path.Object(), Path: path.Inverted(a.Object)})
+		}
+		if len(listed) == fetched {
+			last := slices.Max(maps.Keys(listed))
+			if cutoff == nil || last < *cutoff {
+				cutoff = &last
+			}
+		}
+	}
+
+	nestable, err := nestableTypes(ctx, d.types)
+	if err != nil {
+		return nil, err
+	}
+
+	denied, err := deniedSubjects(ctx, roles, nestable, paths, verb, now)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := map[doorman.Object]bool{}
+	for _, p := range paths {
+		sub, path := p.Subject, p.Path
+		// Without a context, conditional paths can't be followed
+		if unique[sub] || path.Expired(now) || len(path.Conditions()) > 0 {
+			continue
+		}
+		if denied[sub] || denied[sub.Wildcard()] {
+			continue
+		}
+		if request.Transitive && (nestable[sub.Type()] || sub.IsSubjectSet()) {
+			continue
+		}
+		if !request.Transitive && len(path) > 1 {
+			continue
+		}
+
+		granted, err := roles.HasVerb(ctx, path[len(path)-1].Role, verb)
+		if err != nil {
+			return nil, err
+		}
+		if !granted {
+			continue
+		}
+
+		connects, err := roles.Connects(ctx, nestable, path)
+		if err != nil {
+			return nil, err
+		}
+		if !connects {
+			continue
+		}
+
+		unique[sub] = true
+	}
+
+	subjects := make([]doorman.Object, 0, len(unique))
+	for sub := range unique {
+		// The wildcards before the page token are only listed for their denies
+		if sub > after && (cutoff == nil || sub <= *cutoff) {
+			subjects = append(subjects, sub)
+		}
+	}
+	slices.Sort(subjects)
+
+	res := &pb.ListSubjectsResponse{}
+	if len(subjects) > limit {
+		subjects = subjects[:limit]
+		token := string(subjects[limit-1])
+		res.PaginationToken = &token
+	} else if cutoff != nil {
+		// The page can be shorter, but there are more subjects after it
+		token := string(*cutoff)
+		res.PaginationToken = &token
+	}
+
+	res.Items = make([]*pb.Relation, len(subjects))
+	for i, sub := range subjects {
+		res.Items[i] = &pb.Relation{
+			Subject: string(sub),
+			Verb:    string(verb),
+			Object:  string(obj),
+		}
+	}
+
+	return res, nil
+}
+
+// subjectPath is a path from the subject to the object, turned around from an inverted ListConnected.
+type subjectPath struct {
+	Subject doorman.Object
+	Path    doorman.Path
+}
+
+// deniedSubjects finds the subjects denied the verb by the paths, directly or through groups.
+// Conditional denies are included too, as without a context it is not known if they apply.
+func deniedSubjects(ctx context.Context, roles roleResolver, nestable map[string]bool, paths []subjectPath, verb doorman.Verb, now time.Time) (map[doorman.Object]bool, error) {
+	denied := map[doorman.Object]bool{}
+	for _, p := range paths {
+		if p.Path.Expired(now) {
+			continue
+		}
+
+		denies, err := roles.Denies(ctx, p.Path[len(p.Path)-1].Role, verb)
+		if err != nil {
+			return nil, err
+		}
+		connects, err := roles.Connects(ctx, nestable, p.Path)
+		if err != nil {
+			return nil, err
+		}
+		if denies && connects {
+			denied[p.Subject] = true
+		}
+	}
+	return denied, nil
+}
+
+func (d *Doorman) ListTypes(ctx context.Context, request *pb.ListTypesRequest) (*pb.ListTypesResponse, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := d.types.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list failed: %w", err)
+	}
+
+	items := make([]*pb.Type, len(types))
+	for i, t := range types {
+		items[i] = mapTypeToPb(t)
+	}
+
+	return &pb.ListTypesResponse{
+		Items: items,
+	}, nil
+}
+
+// RemoveType fails if any tuples still use the type, as they could not be revoked otherwise.
+func (d *Doorman) RemoveType(ctx context.Context, request *pb.RemoveTypeRequest) (*pb.Type, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	typ, err := d.removeTypeWithTx(ctx, tx, request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return mapTypeToPb(*typ), nil
+}
+
+// UpsertType can only change whether a type is nestable while no tuples use it, as the cache would be stale otherwise.
+func (d *Doorman) UpsertType(ctx context.Context, request *pb.UpsertTypeRequest) (*pb.Type, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	typ := doorman.Type{ID: request.Id, Nestable: request.Nestable}
+	if err := typ.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := d.upsertTypeWithTx(ctx, tx, typ); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return mapTypeToPb(typ), nil
+}
+
+// upsertTypeWithTx records the type in the changes too, so that they can be replayed, see CheckAt.
+func (d *Doorman) upsertTypeWithTx(ctx context.Context, tx db.Tx, typ doorman.Type) error {
+	// Changes are only added while holding the lock
+	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
+		return fmt.Errorf("tuples.Lock failed: %w", err)
+	}
+
+	existing, err := d.types.WithTx(tx).Retrieve(ctx, typ.ID)
+	if err != nil && err != db.ErrInvalidType {
+		return fmt.Errorf("db.Retrieve failed: %w", err)
+	}
+	if existing != nil && existing.Nestable != typ.Nestable {
+		inUse, err := d.tuples.WithTx(tx).HasTuplesForType(ctx, typ.ID)
+		if err != nil {
+			return fmt.Errorf("hasTuplesForType failed: %w", err)
+		}
+		if inUse {
+			return status.Errorf(codes.FailedPrecondition, "type %q is still in use", typ.ID)
+		}
+	}
+
+	if err := d.types.WithTx(tx).Upsert(ctx, typ); err != nil {
+		return fmt.Errorf("types.Upsert failed: %w", err)
+	}
+	if _, err := d.addChangeWithTx(ctx, tx, "TYPE_UPSERTED", typ); err != nil {
+		return err
+	}
+	return nil
+}
+
+// removeTypeWithTx is the opposite of upsertTypeWithTx, types can only be removed while no tuples use them.
+func (d *Doorman) removeTypeWithTx(ctx context.Context, tx db.Tx, id string) (*doorman.Type, error) {
+	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
+		return nil, fmt.Errorf("tuples.Lock failed: %w", err)
+	}
+
+	typ, err := d.types.WithTx(tx).Retrieve(ctx, id)
+	if err != nil {
+		if err == db.ErrInvalidType {
+			return nil, status.Errorf(codes.NotFound, "type %q not found", id)
+		}
+		return nil, fmt.Errorf("db.Retrieve failed: %w", err)
+	}
+
+	inUse, err := d.tuples.WithTx(tx).HasTuplesForType(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("hasTuplesForType failed: %w", err)
+	}
+	if inUse {
+		return nil, status.Errorf(codes.FailedPrecondition, "type %q is still in use", id)
+	}
+
+	if err := d.types.WithTx(tx).Remove(ctx, id); err != nil {
+		return nil, fmt.Errorf("types.Remove failed: %w", err)
+	}
+	if _, err := d.addChangeWithTx(ctx, tx, "TYPE_REMOVED", typ); err != nil {
+		return nil, err
+	}
+	return typ, nil
+}
+
+func (d *Doorman) ListRoles(ctx context.Context, request *pb.ListRolesRequest) (*pb.ListRolesResponse, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := d.roles.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list failed: %w", err)
+	}
+
+	items := make([]*pb.Role, len(roles))
+	for i, r := range roles {
+		items[i] = mapRoleToPb(r)
+	}
+
+	return &pb.ListRolesResponse{
+		Items: items,
+	}, nil
+}
+
+const processTimeout = time.Second * 5
+
+// ProcessChange processes a single pending change of any tenant, the tenants are tried in a random order
+// so that a busy one doesn't hold up the others.
+func (d *Doorman) ProcessChange() error {
+	ctx, cancel := context.WithTimeout(context.Background(), processTimeout)
+	defer cancel()
+
+	ids, err := d.tenants.store.Tenants(ctx)
+	if err != nil {
+		return fmt.Errorf("listing tenants failed: %w", err)
+	}
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+
+	for _, id := range ids {
+		t, err := d.tenants.get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := t.processPendingChange(); err != db.ErrNoChanges {
+			return err
+		}
+	}
+
+	// No rows = no tasks
+	slog.Debug("no tasks, sleeping")
+
+	select {
+	case <-d.processing:
+	case <-time.After(processTimeout):
+	}
+
+	return db.ErrNoChanges
+}
+
+// processPendingChange processes a single pending change of the tenant, returns ErrNoChanges if there are none.
+func (d *Doorman) processPendingChange() error {
+	timeout := processTimeout
+	stalePeriod := time.Hour
+
+	// This timeout should be higher than the "timeout", otherwise the tx.Commit will fail
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+time.Second*2)
+	defer cancel()
+
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin a tx: %w", err)
+	}
+
+	c, err := d.changes.WithTx(tx).ClaimPending(ctx)
+
+	if err == db.ErrNoChanges {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("tx failed to commit: %w", err)
+		}
+		return db.ErrNoChanges
+	}
+
+	// Failed to execute query, probably a bad query/schema
+	if err != nil {
+		if err := tx.Rollback(ctx); err != nil {
+			return fmt.Errorf("failed to rollback: %w", err)
+		}
+		return fmt.Errorf("failed to query/scan: %w", err)
+	}
+
+	// Process task, the cache is persisted within the same tx
+	if err := d.processChange(ctx, tx, c); err != nil {
+		if err := d.sets.Rollback(ctx, tx); err != nil {
+			return fmt.Errorf("failed to rollback: %w", err)
+		}
+
+		time.Sleep(timeout)
+
+		// Tasks older than stalePeriod get logged
+		if time.Since(c.CreatedAt) > stalePeriod {
+			// TODO: probably log this somewhere else
+			slog.Info("stale change", "change", c)
+		}
+
+		return fmt.Errorf("failed to process change %s of tenant %q: %w", c.Type, d.tenant, err)
+	}
+
+	// No errors, so task can be deleted, and the cache updated
+	if err := d.sets.Commit(ctx, tx); err != nil {
+		return fmt.Errorf("tx failed to commit: %w", err)
+	}
+
+	d.local.add(c.ID)
+	d.applied.notify()
+
+	return nil
+}
+
+const (
+	maxConsistencyWait      = time.Second * 5
+	consistencyPollInterval = time.Millisecond * 100
+)
+
+// waitUntilApplied blocks until the change the token refers to has been applied to the cache of this instance.
+// The store is polled as well, as changes can also be processed by other instances,
+// in which case the cache is reloaded from the one they persisted.
+func (d *Doorman) waitUntilApplied(ctx context.Context, token *string) error {
+	if token == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, maxConsistencyWait)
+	defer cancel()
+
+	for {
+		// Must be taken before checking the status, otherwise we could miss the change being applied
+		applied := d.applied.wait()
+
+		change, err := d.changes.Retrieve(ctx, *token)
+		if err == db.ErrInvalidChange {
+			return status.Errorf(codes.InvalidArgument, "invalid consistency token: %s", *token)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("changes.Retrieve failed: %w", err)
+		}
+		if change.Status == "processed" {
+			return d.catchUp(ctx, change.ID)
+		}
+
+		select {
+		case <-applied:
+		case <-time.After(consistencyPollInterval):
+		case <-ctx.Done():
+		}
+	}
+
+	return status.Errorf(codes.Unavailable, "change %s has not been applied yet, try again later", *token)
+}
+
+// waitUntilCaughtUp waits until the changes committed so far are applied, for the checks that can't be stale.
+func (d *Doorman) waitUntilCaughtUp(ctx context.Context) error {
+	last, err := d.changes.LastSeq(ctx)
+	if err != nil {
+		return fmt.Errorf("changes.LastSeq failed: %w", err)
+	}
+	if last == 0 {
+		return nil
+	}
+
+	// One at a time, as they are processed in no particular order
+	pending, limit := "pending", 1
+	for {
+		changes, err := d.changes.List(ctx, db.ChangeFilter{Status: &pending, Until: &last, Limit: &limit})
+		if err != nil {
+			return fmt.Errorf("changes.List failed: %w", err)
+		}
+		if len(changes) == 0 {
+			break
+		}
+		if err := d.waitUntilApplied(ctx, &changes[0].ID); err != nil {
+			return err
+		}
+	}
+
+	after := last - 1
+	changes, err := d.changes.List(ctx, db.ChangeFilter{After: &after, Limit: &limit})
+	if err != nil {
+		return fmt.Errorf("changes.List failed: %w", err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return d.catchUp(ctx, changes[0].ID)
+}
+
+// catchUp reloads the cache if the processed change wasn't applied by this instance.
+func (d *Doorman) catchUp(ctx context.Context, id string) error {
+	if d.local.contains(id) {
+		return nil
+	}
+
+	// The cache is persisted in the same tx the change is processed in, so it includes the change
+	if err := d.sets.Load(ctx); err != nil {
+		return fmt.Errorf("sets.Load failed: %w", err)
+	}
+	d.local.add(id)
+	return nil
+}
+
+// LoadCache loads the caches persisted by previously processed changes.
+// The caches of the tenants that haven't been used yet are loaded once they are.
+func (d *Doorman) LoadCache(ctx context.Context) error {
+	for _, t := range d.tenants.all() {
+		if err := t.sets.Load(ctx); err != nil {
+			return fmt.Errorf("loading the cache of tenant %q failed: %w", t.tenant, err)
+		}
+	}
+	return nil
+}
+
+// RebuildAllCaches rebuilds the caches of every tenant, see RebuildCache.
+func (d *Doorman) RebuildAllCaches(ctx context.Context) error {
+	return d.tenants.each(ctx, func(d *Doorman) error {
+		return d.changes.SetStatusOfAll(ctx, "pending")
+	})
+}
+
+func (d *Doorman) RebuildCache(ctx context.Context, request *pb.RebuildCacheRequest) (*pb.RebuildCacheResponse, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := d.changes.SetStatusOfAll(ctx, "pending"); err != nil {
+		return nil, fmt.Errorf("marking all as pending failed: %w", err)
+	}
+	return &pb.RebuildCacheResponse{}, nil
+}
+
+// Export streams the types first, then the roles and then the tuples.
+func (d *Doorman) Export(request *pb.ExportRequest, stream pb.Doorman_ExportServer) error {
+	ctx := stream.Context()
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	types, err := d.types.List(ctx)
+	if err != nil {
+		return fmt.Errorf("types.List failed: %w", err)
+	}
+	for _, t := range types {
+		if err := stream.Send(&pb.Record{Record: &pb.Record_Type{Type: mapTypeToPb(t)}}); err != nil {
+			return err
+		}
+	}
+
+	roles, err := d.roles.List(ctx)
+	if err != nil {
+		return fmt.Errorf("roles.List failed: %w", err)
+	}
+	for _, r := range roles {
+		if err := stream.Send(&pb.Record{Record: &pb.Record_Role{Role: mapRoleToPb(r)}}); err != nil {
+			return err
+		}
+	}
+
+	tuples, err := d.tuples.List(ctx)
+	if err != nil {
+		return fmt.Errorf("tuples.List failed: %w", err)
+	}
+	for _, t := range tuples {
+		if err := stream.Send(&pb.Record{Record: &pb.Record_Tuple{Tuple: mapTupleToPb(t)}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Import adds the records in a single tx, after revoking and removing everything that exists if the mode is REPLACE.
+// Unlike in Grant, the tuples are locked once for the whole import. Tuples that have expired since they were exported are skipped.
+func (d *Doorman) Import(stream pb.Doorman_ImportServer) error {
+	ctx := stream.Context()
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := d.authorize(ctx); err != nil {
+		return err
+	}
+
+	// Locked up front, so that nothing changes between the batches
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
+		return fmt.Errorf("tuples.Lock failed: %w", err)
+	}
+
+	var imp *importer
+	var types []doorman.Type
+	var roles []doorman.Role
+	var tuples []doorman.Tuple
+	for {
+		req, err := stream.Recv()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if imp == nil {
+			imp, err = d.newImporter(ctx, tx, req.GetMode())
+			if err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+
+		switch r := req.GetRecord().GetRecord().(type) {
+		case *pb.Record_Type:
+			types = append(types, doorman.Type{ID: r.Type.Id, Nestable: r.Type.Nestable})
+		case *pb.Record_Role:
+			roles = append(roles, mapRoleFromPb(r.Role))
+		case *pb.Record_Tuple:
+			tuples = append(tuples, mapTupleFromPb(r.Tuple))
+		default:
+			return status.Error(codes.InvalidArgument, "record must be a type, a role or a tuple")
+		}
+
+		if len(types)+len(roles)+len(tuples) >= importBatchSize {
+			if err := imp.add(ctx, types, roles, tuples); err != nil {
+				return err
+			}
+			types, roles, tuples = nil, nil, nil
+		}
+	}
+
+	if err := imp.add(ctx, types, roles, tuples); err != nil {
+		return err
+	}
+	res, err := imp.finish(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return stream.SendAndClose(res)
+}
+
+// The records Import buffers before adding them
+const importBatchSize = 1000
+
+func (d *Doorman) importWithTx(ctx context.Context, tx db.Tx, mode pb.ImportMode, types []doorman.Type, roles []doorman.Role, tuples []doorman.Tuple) (*pb.ImportResponse, error) {
+	imp, err := d.newImporter(ctx, tx, mode)
+	if err != nil {
+		return nil, err
+	}
+	if err := imp.add(ctx, types, roles, tuples); err != nil {
+		return nil, err
+	}
+	return imp.finish(ctx)
+}
+
+// importer adds the records of an import within the tx, a batch at a time.
+type importer struct {
+	d   *Doorman
+	tx  db.Tx
+	res *pb.ImportResponse
+
+	// Roles are validated once the tuples start, as they can include the ones after them
+	added []doorman.Role
+}
+
+// newImporter removes everything first if replacing.
+func (d *Doorman) newImporter(ctx context.Context, tx db.Tx, mode pb.ImportMode) (*importer, error) {
+	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
+		return nil, fmt.Errorf("tuples.Lock failed: %w", err)
+	}
+
+	imp := &importer{d: d, tx: tx, res: &pb.ImportResponse{}}
+	if mode != pb.ImportMode_REPLACE {
+		return imp, nil
+	}
+
+	existing, err := d.tuples.WithTx(tx).List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("tuples.List failed: %w", err)
+	}
+	for _, t := range existing {
+		if err := d.tuples.WithTx(tx).Remove(ctx, t); err != nil {
+			return nil, fmt.Errorf("tuples.Remove failed: %w", err)
+		}
+		if err := imp.addChange(ctx, "REVOKED", t); err != nil {
+			return nil, err
+		}
+	}
+
+	existingRoles, err := d.roles.WithTx(tx).List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("roles.List failed: %w", err)
+	}
+	for _, r := range existingRoles {
+		if err := d.roles.WithTx(tx).Remove(ctx, r.ID); err != nil {
+			return nil, fmt.Errorf("roles.Remove failed: %w", err)
+		}
+		if err := imp.addChange(ctx, "ROLE_REMOVED", r); err != nil {
+			return nil, err
+		}
+	}
+
+	existingTypes, err := d.types.WithTx(tx).List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("types.List failed: %w", err)
+	}
+	for _, t := range existingTypes {
+		if _, err := d.removeTypeWithTx(ctx, tx, t.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return imp, nil
+}
+
+func (imp *importer) addChange(ctx context.Context, typ string, payload any) error {
+	change, err := imp.d.addChangeWithTx(ctx, imp.tx, typ, payload)
+	if err != nil {
+		return err
+	}
+	imp.res.ConsistencyToken = &change.ID
+	return nil
+}
+
+// validateRoles validates the roles added so far, and records them.
+func (imp *importer) validateRoles(ctx context.Context) error {
+	for _, r := range imp.added {
+		if err := validateRole(ctx, r, imp.d.roles.WithTx(imp.tx).Retrieve); err != nil {
+			return err
+		}
+		if err := imp.addChange(ctx, "ROLE_UPSERTED", r); err != nil {
+			return err
+		}
+		imp.res.Roles++
+	}
+	imp.added = nil
+	return nil
+}
+
+// finish validates the roles that are left, and returns what was imported.
+func (imp *importer) finish(ctx context.Context) (*pb.ImportResponse, error) {
+	if err := imp.validateRoles(ctx); err != nil {
+		return nil, err
+	}
+	return imp.res, nil
+}
+
+// add adds the records that don't exist yet, and fails if one conflicts with an existing one.
+func (imp *importer) add(ctx context.Context, types []doorman.Type, roles []doorman.Role, tuples []doorman.Tuple) error {
+	d, tx, res := imp.d, imp.tx, imp.res
+
+	for _, t := range types {
+		if err := t.Validate(); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		existing, err := d.types.WithTx(tx).Retrieve(ctx, t.ID)
+		if err == nil {
+			if *existing != t {
+				return status.Errorf(codes.FailedPrecondition, "type %s differs from the existing one", t.ID)
+			}
+			continue
+		}
+		if err != db.ErrInvalidType {
+			return fmt.Errorf("types.Retrieve failed: %w", err)
+		}
+
+		if err := d.upsertTypeWithTx(ctx, tx, t); err != nil {
+			return err
+		}
+		res.Types++
+	}
+
+	for _, r := range roles {
+		existing, err := d.roles.WithTx(tx).Retrieve(ctx, r.ID)
+		if err == nil {
+			if !sameRole(*existing, r) {
+				return status.Errorf(codes.FailedPrecondition, "role %s differs from the existing one", r.ID)
+			}
+			continue
+		}
+		if err != db.ErrInvalidRole {
+			return fmt.Errorf("roles.Retrieve failed: %w", err)
+		}
+
+		if err := d.roles.WithTx(tx).Upsert(ctx, &r); err != nil {
+			return fmt.Errorf("roles.Upsert failed: %w", err)
+		}
+		imp.added = append(imp.added, r)
+	}
+
+	// Before the tuples, as replaying their changes needs the roles recorded first
+	if len(tuples) > 0 {
+		if err := imp.validateRoles(ctx); err != nil {
+			return err
+		}
+	}
+
+	typeResolver := newTypeResolver(d.types.WithTx(tx))
+	now := time.Now()
+	for _, t := range tuples {
+		if t.Expired(now) {
+			continue
+		}
+		err := validateGrant(ctx, typeResolver, d.roles.WithTx(tx), &pb.GrantRequest{
+			Subject:   string(t.Subject),
+			Role:      t.Role,
+			Object:    string(t.Object),
+			Condition: t.Condition,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Checked before adding, as a failed insert aborts the whole tx in postgres
+		between, err := d.tuples.WithTx(tx).ListTuplesBetween(ctx, t.Subject, t.Object)
+		if err != nil {
+			return fmt.Errorf("tuples.ListTuplesBetween failed: %w", err)
+		}
+		if i := slices.IndexFunc(between, t.Equal); i >= 0 {
+			if !sameTuple(between[i], t) {
+				return status.Errorf(codes.FailedPrecondition, "tuple %s differs from the existing one", t)
+			}
+			continue
+		}
+
+		if err := d.tuples.WithTx(tx).Add(ctx, t); err != nil {
+			return err
+		}
+		if err := imp.addChange(ctx, "GRANTED", t); err != nil {
+			return err
+		}
+		res.Tuples++
+	}
+
+	return nil
+}
+
+// Apply upserts the roles of the manifest first, then revokes the tuples that differ and grants the ones that are missing,
+// and finally removes the roles that aren't in it if pruning. A dry run is rolled back instead of committed.
+func (d *Doorman) Apply(ctx context.Context, request *pb.ApplyRequest) (*pb.ApplyResponse, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	manifest := request.GetManifest()
+
+	roles := make([]doorman.Role, len(manifest.GetRoles()))
+	declared := map[string]bool{}
+	for i, r := range manifest.GetRoles() {
+		if declared[r.Id] {
+			return nil, status.Errorf(codes.InvalidArgument, "role %s is declared twice", r.Id)
+		}
+		declared[r.Id] = true
+		roles[i] = mapRoleFromPb(r)
+	}
+
+	tuples := make([]doorman.Tuple, len(manifest.GetTuples()))
+	for i, t := range manifest.GetTuples() {
+		tuples[i] = mapTupleFromPb(t)
+		if slices.ContainsFunc(tuples[:i], tuples[i].Equal) {
+			return nil, status.Errorf(codes.InvalidArgument, "tuple %s is declared twice", tuples[i])
+		}
+		// Otherwise it would be revoked again once its role is pruned
+		if request.Prune && !declared[t.Role] {
+			return nil, status.Errorf(codes.InvalidArgument, "tuple %s: role %s is not declared", tuples[i], t.Role)
+		}
+	}
+
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	res, err := d.applyWithTx(ctx, tx, roles, tuples, request.Prune)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.DryRun {
+		res.ConsistencyToken = nil
+		if err := tx.Rollback(ctx); err != nil {
+			return nil, fmt.Errorf("tx.Rollback failed: %w", err)
+		}
+		return res, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return res, nil
+}
+
+func (d *Doorman) applyWithTx(ctx context.Context, tx db.Tx, roles []doorman.Role, tuples []doorman.Tuple, prune bool) (*pb.ApplyResponse, error) {
+	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
+		return nil, fmt.Errorf("tuples.Lock failed: %w", err)
+	}
+
+	existingRoles, err := d.roles.WithTx(tx).List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("roles.List failed: %w", err)
+	}
+	existingTuples, err := d.tuples.WithTx(tx).List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("tuples.List failed: %w", err)
+	}
+
+	res := &pb.ApplyResponse{}
+	diff := func(typ pb.DiffType, item any) {
+		switch item := item.(type) {
+		case doorman.Role:
+			res.Diffs = append(res.Diffs, &pb.Diff{Type: typ, Item: &pb.Diff_Role{Role: mapRoleToPb(item)}})
+		case doorman.Tuple:
+			res.Diffs = append(res.Diffs, &pb.Diff{Type: typ, Item: &pb.Diff_Tuple{Tuple: mapTupleToPb(item)}})
+		}
+	}
+
+	existing := map[string]doorman.Role{}
+	for _, r := range existingRoles {
+		existing[r.ID] = r
+	}
+	for _, r := range includedFirst(roles) {
+		typ := pb.DiffType_ADDED
+		if e, ok := existing[r.ID]; ok {
+			if sameRole(e, r) {
+				continue
+			}
+			typ = pb.DiffType_CHANGED
+		}
+
+		role := mapRoleToPb(r)
+		_, err := d.upsertRoleWithTx(ctx, tx, &pb.UpsertRoleRequest{Id: role.Id, Verbs: role.Verbs, Includes: role.Includes, Deny: role.Deny, Parent: role.Parent})
+		if err != nil {
+			return nil, fmt.Errorf("upserting role %s failed: %w", r.ID, err)
+		}
+		diff(typ, r)
+	}
+
+	// Revoked before granting, so that the tuples that differ can be granted again
+	declared := map[string]doorman.Tuple{}
+	for _, t := range tuples {
+		declared[t.String()] = t
+	}
+	current := map[string]doorman.Tuple{}
+	for _, t := range existingTuples {
+		current[t.String()] = t
+
+		want, ok := declared[t.String()]
+		if ok && sameTuple(t, want) || !ok && !prune {
+			continue
+		}
+		revoked, err := d.revokeWithTx(ctx, tx, &pb.RevokeRequest{Subject: string(t.Subject), Role: t.Role, Object: string(t.Object)})
+		if err != nil {
+			return nil, fmt.Errorf("revoking %s failed: %w", t, err)
+		}
+		res.ConsistencyToken = &revoked.ConsistencyToken
+		if !ok {
+			diff(pb.DiffType_REMOVED, t)
+		}
+	}
+
+	types := newTypeResolver(d.types.WithTx(tx))
+	for _, t := range tuples {
+		typ := pb.DiffType_ADDED
+		if c, ok := current[t.String()]; ok {
+			if sameTuple(c, t) {
+				continue
+			}
+			typ = pb.DiffType_CHANGED
+		}
+
+		request := &pb.GrantRequest{
+			Subject:   string(t.Subject),
+			Role:      t.Role,
+			Object:    string(t.Object),
+			ExpiresAt: mapTimeToPb(t.ExpiresAt),
+			Condition: t.Condition,
+		}
+		if t.Expired(time.Now()) {
+			return nil, status.Errorf(codes.InvalidArgument, "tuple %s: expires_at must be in the future", t)
+		}
+		if err := validateGrant(ctx, types, d.roles.WithTx(tx), request); err != nil {
+			return nil, err
+		}
+		granted, err := d.grantWithTx(ctx, tx, request)
+		if err != nil {
+			return nil, fmt.Errorf("granting %s failed: %w", t, err)
+		}
+		res.ConsistencyToken = &granted.ConsistencyToken
+		diff(typ, t)
+	}
+
+	if prune {
+		var pruned []doorman.Role
+		for _, r := range existingRoles {
+			if !slices.ContainsFunc(roles, func(want doorman.Role) bool { return want.ID == r.ID }) {
+				pruned = append(pruned, r)
+			}
+		}
+		// The roles including others have to be removed first
+		pruned = includedFirst(pruned)
+		slices.Reverse(pruned)
+		for _, r := range pruned {
+			if _, err := d.removeRoleWithTx(ctx, tx, r.ID); err != nil {
+				return nil, fmt.Errorf("removing role %s failed: %w", r.ID, err)
+			}
+			diff(pb.DiffType_REMOVED, r)
+		}
+	}
+
+	return res, nil
+}
+
+// includedFirst orders the roles so that the roles they include come before them.
+func includedFirst(roles []doorman.Role) []doorman.Role {
+	byID := map[string]doorman.Role{}
+	for _, r := range roles {
+		byID[r.ID] = r
+	}
+
+	ordered := []doorman.Role{}
+	done := map[string]bool{}
+	var visit func(r doorman.Role)
+	visit = func(r doorman.Role) {
+		if done[r.ID] {
+			return
+		}
+		done[r.ID] = true
+		for _, id := range r.Includes {
+			if included, ok := byID[id]; ok {
+				visit(included)
+			}
+		}
+		ordered = append(ordered, r)
+	}
+	for _, r := range roles {
+		visit(r)
+	}
+	return ordered
+}
+
+// sameRole compares roles regardless of the order of their verbs and includes.
+func sameRole(a, b doorman.Role) bool {
+	sorted := func(s []string) []string {
+		s = slices.Clone(s)
+		slices.Sort(s)
+		return s
+	}
+	verbs := func(r doorman.Role) []string {
+		verbs := make([]string, len(r.Verbs))
+		for i, v := range r.Verbs {
+			verbs[i] = string(v)
+		}
+		return sorted(verbs)
+	}
+	return a.ID == b.ID && a.Deny == b.Deny && a.Parent == b.Parent &&
+		slices.Equal(verbs(a), verbs(b)) && slices.Equal(sorted(a.Includes), sorted(b.Includes))
+}
+
+// sameTuple also compares when the tuples expire and their conditions.
+func sameTuple(a, b doorman.Tuple) bool {
+	sameExpiry := a.ExpiresAt == nil && b.ExpiresAt == nil ||
+		a.ExpiresAt != nil && b.ExpiresAt != nil && a.ExpiresAt.Equal(*b.ExpiresAt)
+	return a.Equal(b) && sameExpiry && a.Condition == b.Condition
+}
+
+func (d *Doorman) RemoveRole(ctx context.Context, request *pb.RemoveRoleRequest) (*pb.Role, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := d.removeRoleWithTx(ctx, tx, request.Id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return &pb.Role{}, nil
+}
+
+// removeRoleWithTx revokes the tuples of the role before removing it, and returns the role as it was.
+func (d *Doorman) removeRoleWithTx(ctx context.Context, tx db.Tx, id string) (*doorman.Role, error) {
+	roles := d.roles.WithTx(tx)
+
+	role, err := roles.Retrieve(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("db.Retrieve failed: %w", err)
+	}
+
+	including, err := rolesIncluding(ctx, roles, role.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(including) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "role is included by %s", strings.Join(including, ", "))
+	}
+
+	tuples, err := d.tuples.WithTx(tx).ListTuplesForRole(ctx, role.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ListTuplesForRole failed: %w", err)
+	}
+
+	for _, t := range tuples {
+		_, err := d.revokeWithTx(ctx, tx, &pb.RevokeRequest{
+			Subject: string(t.Subject),
+			Role:    role.ID,
+			Object:  string(t.Object),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("revoke failed for %s: %w", t, err)
+		}
+	}
+
+	if err := roles.Remove(ctx, id); err != nil {
+		return nil, fmt.Errorf("update failed: %w", err)
+	}
+
+	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
+		return nil, fmt.Errorf("tuples.Lock failed: %w", err)
+	}
+	if _, err := d.addChangeWithTx(ctx, tx, "ROLE_REMOVED", role); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (d *Doorman) Revoke(ctx context.Context, request *pb.RevokeRequest) (*pb.RevokeResponse, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.authorizeTuple(ctx, doorman.Object(request.Subject), request.Role, doorman.Object(request.Object)); err != nil {
+		return nil, err
+	}
+
+	types := newTypeResolver(d.types)
+	if err := types.Validate(ctx, doorman.Object(request.Subject), doorman.Object(request.Object)); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	res, err := d.revokeWithTx(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return res, nil
+}
+
+// RevokeExpired revokes the tuples of every tenant that have expired, each in its own tx.
+func (d *Doorman) RevokeExpired(ctx context.Context) error {
+	return d.tenants.each(ctx, func(d *Doorman) error {
+		now := time.Now()
+		expired, err := d.tuples.ListExpiredTuples(ctx, now)
+		if err != nil {
+			return fmt.Errorf("tuples.ListExpiredTuples failed: %w", err)
+		}
+
+		for _, tuple := range expired {
+			if err := d.revokeExpired(ctx, tuple, now); err != nil {
+				return fmt.Errorf("revoking %s failed: %w", tuple, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *Doorman) revokeExpired(ctx context.Context, tuple doorman.Tuple, now time.Time) error {
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
+		return fmt.Errorf("tuples.Lock failed: %w", err)
+	}
+
+	// It might have been revoked or granted again since it was listed
+	tuples, err := d.tuples.WithTx(tx).ListTuplesBetween(ctx, tuple.Subject, tuple.Object)
+	if err != nil {
+		return fmt.Errorf("tuples.ListTuplesBetween failed: %w", err)
+	}
+	i := slices.IndexFunc(tuples, func(t doorman.Tuple) bool {
+		return t.Equal(tuple) && t.ExpiresAt != nil && t.ExpiresAt.Before(now)
+	})
+	if i < 0 {
+		return nil
+	}
+
+	_, err = d.revokeWithTx(ctx, tx, &pb.RevokeRequest{
+		Subject: string(tuple.Subject),
+		Role:    tuple.Role,
+		Object:  string(tuple.Object),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return nil
+}
+
+func (d *Doorman) UpsertRole(ctx context.Context, request *pb.UpsertRoleRequest) (*pb.Role, error) {
+	d, err := d.forTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.store.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	role, err := d.upsertRoleWithTx(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("tx.Commit failed: %w", err)
+	}
+
+	d.changesCommitted()
+
+	return mapRoleToPb(*role), nil
+}
+
+// upsertRoleWithTx revokes the tuples of the role and of the roles including it, and grants them again once it is upserted.
+func (d *Doorman) upsertRoleWithTx(ctx context.Context, tx db.Tx, request *pb.UpsertRoleRequest) (*doorman.Role, error) {
+	// Locking before reading, so that the roles including this one and their tuples can't change meanwhile
+	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
+		return nil, fmt.Errorf("tuples.Lock failed: %w", err)
+	}
+
+	roles := d.roles.WithTx(tx)
+
+	var previous *doorman.Role
+	role, err := roles.Retrieve(ctx, request.Id)
+	if err == db.ErrInvalidRole {
+		role = &doorman.Role{ID: request.Id}
+	} else if err != nil {
+		return nil, fmt.Errorf("db.Retrieve failed: %w", err)
+	} else {
+		p := *role
+		previous = &p
+	}
+
+	upserted := mapRoleFromPb(&pb.Role{Id: role.ID, Verbs: request.Verbs, Includes: request.Includes, Deny: request.Deny, Parent: request.Parent})
+	if err := validateRole(ctx, upserted, roles.Retrieve); err != nil {
+		return nil, err
+	}
+
+	including, err := rolesIncluding(ctx, roles, role.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(including) > 0 && (role.Deny != request.Deny || role.Parent != request.Parent) {
+		return nil, status.Errorf(codes.FailedPrecondition, "role is included by %s", strings.Join(including, ", "))
+	}
+
+	role.Verbs = upserted.Verbs
+	role.Includes = upserted.Includes
+	role.Deny = upserted.Deny
+	role.Parent = upserted.Parent
+
+	if err := roles.Upsert(ctx, role); err != nil {
+		return nil, fmt.Errorf("update failed: %w", err)
+	}
+
+	// The sets of its tuples, and of the roles including it, are refreshed once the change is processed
+	if _, err := d.addChangeWithTx(ctx, tx, "ROLE_UPSERTED", roleUpserted{Role: *role, Previous: previous}); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// validateRole checks a role before it is upserted, the roles it includes are retrieved with retrieve.
+func validateRole(ctx context.Context, role doorman.Role, retrieve func(ctx context.Context, id string) (*doorman.Role, error)) error {
+	for _, v := range role.Verbs {
+		if strings.HasPrefix(string(v), "!") {
+			return status.Errorf(codes.InvalidArgument, "verb %q can't start with !", v)
+		}
+		if role.Deny && v == "inherits" {
+			return status.Error(codes.InvalidArgument, "deny roles can't inherit, deny the verbs on the objects instead")
+		}
+		if role.Parent && v == "inherits" {
+			return status.Error(codes.InvalidArgument, "parent roles can't inherit, use a nestable type instead")
+		}
+	}
+	if role.Deny && role.Parent {
+		return status.Error(codes.InvalidArgument, "a role can't be both a deny and a parent role")
+	}
+
+	resolve := func(ctx context.Context, id string) (*doorman.Role, error) {
+		if id == role.ID {
+			return &role, nil
+		}
+		return retrieve(ctx, id)
+	}
+	included, err := doorman.ResolveRoles(ctx, role.ID, resolve)
+	if err != nil {
+		if errors.Is(err, doorman.ErrRoleCycle) || errors.Is(err, db.ErrInvalidRole) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return err
+	}
+
+	// Roles can only include roles of the same kind, as otherwise it's unclear if a verb is granted, denied or flows
+	for _, r := range included {
+		if r.Deny != role.Deny || r.Parent != role.Parent {
+			return status.Errorf(codes.InvalidArgument, "%s and %s have to be the same kind of role", role.ID, r.ID)
+		}
+	}
+
+	return nil
+}
+
+// rolesIncluding lists the roles that include the role, transitively.
+func rolesIncluding(ctx context.Context, roles db.RoleStore, id string) ([]string, error) {
+	all, err := roles.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("roles.List failed: %w", err)
+	}
+
+	including := []string{}
+	for _, r := range all {
+		if r.ID == id {
+			continue
+		}
+		resolved, err := doorman.ResolveRoles(ctx, r.ID, roles.Retrieve)
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(resolved, func(r doorman.Role) bool { return r.ID == id }) {
+			including = append(including, r.ID)
+		}
+	}
+	return including, nil
 }
 
-func (d *Doorman) grantWithTx(ctx context.Context, tx pgx.Tx, request *pb.GrantRequest) (*pb.GrantResponse, error) {
+func (d *Doorman) grantWithTx(ctx context.Context, tx db.Tx, request *pb.GrantRequest) (*pb.GrantResponse, error) {
 	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
-		return nil, fmt.Errorf("tuples.Lock failed: %w, %w", err, tx.Rollback(ctx))
+		return nil, fmt.Errorf("tuples.Lock failed: %w", err)
 	}
 
 	tuple := doorman.Tuple{
@@ -344,38 +2239,34 @@ func (d *Doorman) grantWithTx(ctx context.Context, tx pgx.Tx, request *pb.GrantR
 		Role:    request.Role,
 		Object:  doorman.Object(request.Object),
 	}
+	if request.ExpiresAt != nil {
+		expiresAt := request.ExpiresAt.AsTime()
+		tuple.ExpiresAt = &expiresAt
+	}
+	tuple.Condition = request.Condition
 	if err := d.tuples.WithTx(tx).Add(ctx, tuple); err != nil {
-		if err := tx.Rollback(ctx); err != nil {
-			return nil, fmt.Errorf("rollback failed after failing to add tuple: %w", err)
-		}
 		return nil, err
 	}
 
-	payload, err := json.Marshal(tuple)
+	change, err := d.addChangeWithTx(ctx, tx, "GRANTED", tuple)
 	if err != nil {
-		return nil, fmt.Errorf("json marshaling failed: %w", err)
-	}
-
-	change := doorman.Change{
-		ID:        xid.New().String(),
-		Type:      "GRANTED",
-		Payload:   payload,
-		CreatedAt: time.Now(),
-	}
-
-	if err := d.changes.WithTx(tx).Add(ctx, change); err != nil {
-		return nil, fmt.Errorf("adding change failed: %w", err)
+		return nil, err
 	}
 
-	return &pb.GrantResponse{}, nil
+	return &pb.GrantResponse{ConsistencyToken: change.ID}, nil
 }
 
-func (d *Doorman) processChange(ctx context.Context, change doorman.Change) error {
+func (d *Doorman) processChange(ctx context.Context, tx db.Tx, change doorman.Change) error {
 	switch change.Type {
 	case "GRANTED":
-		return d.processChangeGrantedOrRevoked(ctx, change)
+		return d.processChangeGrantedOrRevoked(ctx, tx, change)
 	case "REVOKED":
-		return d.processChangeGrantedOrRevoked(ctx, change)
+		return d.processChangeGrantedOrRevoked(ctx, tx, change)
+	case "ROLE_UPSERTED", "ROLE_REMOVED":
+		return d.processChangeRole(ctx, tx, change)
+	case "TYPE_UPSERTED", "TYPE_REMOVED":
+		// Nothing to do either, types can only change while no tuples use them
+		return nil
 	default:
 		slog.Warn("unhandled change", "type", change.Type)
 	}
@@ -383,13 +2274,8 @@ func (d *Doorman) processChange(ctx context.Context, change doorman.Change) erro
 	return nil
 }
 
-func (d *Doorman) processChangeGrantedOrRevoked(ctx context.Context, change doorman.Change) error {
-	tx, err := d.conn.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("begin tx failed: %w", err)
-	}
-
-	// TODO: think if we can remove locking + tx here?
+func (d *Doorman) processChangeGrantedOrRevoked(ctx context.Context, tx db.Tx, change doorman.Change) error {
+	// TODO: think if we can remove locking here?
 	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
 		return err
 	}
@@ -399,10 +2285,87 @@ func (d *Doorman) processChangeGrantedOrRevoked(ctx context.Context, change door
 		return fmt.Errorf("json unmarshal failed: %w", err)
 	}
 
+	return d.refreshTuple(ctx, tx, tuple, change.Type == "REVOKED")
+}
+
+// roleUpserted is the payload of ROLE_UPSERTED. It is read as the role elsewhere, the previous one is only
+// needed to refresh the sets of the verbs the role no longer has.
+type roleUpserted struct {
+	doorman.Role
+	Previous *doorman.Role `json:"previous,omitempty"`
+}
+
+// processChangeRole refreshes the sets of the tuples with the role, and with the roles including it.
+// A removed role has no tuples left, as they are revoked before it is removed.
+func (d *Doorman) processChangeRole(ctx context.Context, tx db.Tx, change doorman.Change) error {
+	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
+		return err
+	}
+
+	var role roleUpserted
+	if err := json.Unmarshal(change.Payload, &role); err != nil {
+		return fmt.Errorf("json unmarshal failed: %w", err)
+	}
+
+	including, err := rolesIncluding(ctx, d.roles.WithTx(tx), role.ID)
+	if err != nil {
+		return err
+	}
+
+	nestable, err := nestableTypes(ctx, d.types.WithTx(tx))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range append([]string{role.ID}, including...) {
+		tuples, err := d.tuples.WithTx(tx).ListTuplesForRole(ctx, id)
+		if err != nil {
+			return fmt.Errorf("ListTuplesForRole failed: %w", err)
+		}
+
+		// Refreshed as if revoked, as the role might have stopped connecting the objects it used to
+		for _, tuple := range tuples {
+			if err := d.refreshTuple(ctx, tx, tuple, true); err != nil {
+				return err
+			}
+			if role.Previous != nil {
+				if err := d.refreshPreviousSets(ctx, tx, nestable, *role.Previous, tuple); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// refreshPreviousSets refreshes the sets the tuple put its subject and object in while the role was still previous,
+// so that the ones of the verbs it no longer has are emptied.
+func (d *Doorman) refreshPreviousSets(ctx context.Context, tx db.Tx, nestable map[string]bool, previous doorman.Role, tuple doorman.Tuple) error {
+	roles := newRoleResolver(d.roles.WithTx(tx))
+	roles.cache[previous.ID] = &previous
+
+	if sets, err := doorman.RoleSets(ctx, tuple.Role, tuple.Object, roles.Retrieve); err == nil {
+		if err := d.refreshSubsets(ctx, tx, nestable, tuple.Object, sets); err != nil {
+			return err
+		}
+	}
+
+	if inherited, err := inheritedSets(ctx, roles, tuple); err == nil {
+		if err := d.refreshSubsets(ctx, tx, nestable, tuple.Subject, maps.Keys(inherited)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// refreshTuple refreshes the sets a granted or revoked tuple affects.
+func (d *Doorman) refreshTuple(ctx context.Context, tx db.Tx, tuple doorman.Tuple, revoked bool) error {
 	var staleObjects []doorman.Path
+	var err error
 
-	a := time.Now()
-	if change.Type == "GRANTED" {
+	if !revoked {
 		staleObjects, err = d.tuples.WithTx(tx).ListConnected(ctx, tuple.Subject, false)
 		if err != nil {
 			return fmt.Errorf("listConnected failed: %w", err)
@@ -425,65 +2388,127 @@ func (d *Doorman) processChangeGrantedOrRevoked(ctx context.Context, change door
 			path := append(removedPath, incompletePath...)
 			staleObjects = append(staleObjects, path)
 		}
-	}
-	fmt.Println("fetch", time.Since(a))
 
-	a = time.Now()
+		// As well as the ones connected through the subject set of the removed role
+		if set, ok := tuple.Object.SubjectSet(tuple.Role); ok {
+			staleObjectsViaSet, err := d.tuples.WithTx(tx).ListConnected(ctx, set, false)
+			if err != nil {
+				return fmt.Errorf("listConnected failed: %w", err)
+			}
+
+			for _, incompletePath := range staleObjectsViaSet {
+				path := append(doorman.Path{removedPath[0], {Object: set}}, incompletePath...)
+				staleObjects = append(staleObjects, path)
+			}
+		}
+	}
 
 	if err := d.refreshParents(ctx, tx, tuple.Subject); err != nil {
 		return err
 	}
 
-	fmt.Println("refreshParents", time.Since(a))
+	nestable, err := nestableTypes(ctx, d.types.WithTx(tx))
+	if err != nil {
+		return err
+	}
 
-	a = time.Now()
+	// Children check their parents' sets when needed, so only the link itself has to be refreshed
+	if err := d.refreshChildren(ctx, tx, nestable, tuple); err != nil {
+		return err
+	}
 
-	fmt.Println("stale", len(staleObjects))
 	for _, path := range staleObjects {
-		if tuple.Subject.Type() == "group" {
+		if nestable[tuple.Subject.Type()] || tuple.Subject.IsSubjectSet() {
 			p := path[len(path)-1]
-			if err := d.refreshGroups(ctx, tx, p.Object, p.Role); err != nil {
+			if err := d.refreshGroups(ctx, tx, nestable, p.Object, p.Role); err != nil {
 				return err
 			}
 		}
 	}
 
-	fmt.Println("refreshGroups", time.Since(a))
+	return nil
+}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("commit failed: %w", err)
+func (d *Doorman) refreshGroups(ctx context.Context, tx db.Tx, nestable map[string]bool, obj doorman.Object, roleId string) error {
+	sets, err := doorman.RoleSets(ctx, roleId, obj, d.roles.Retrieve)
+	if err != nil {
+		// WHY not error? Because this might run after a role is removed (from rebuild cache)
+		return nil
 	}
 
-	return nil
+	return d.refreshSubsets(ctx, tx, nestable, obj, sets)
 }
 
-func (d *Doorman) refreshGroups(ctx context.Context, tx pgx.Tx, obj doorman.Object, roleId string) error {
-	a := time.Now()
-	connectedSubjects, err := d.tuples.WithTx(tx).ListConnected(ctx, obj, true)
+// refreshChildren refreshes the sets the subject of a tuple with a parent role inherits from the object.
+func (d *Doorman) refreshChildren(ctx context.Context, tx db.Tx, nestable map[string]bool, tuple doorman.Tuple) error {
+	inherited, err := inheritedSets(ctx, newRoleResolver(d.roles), tuple)
 	if err != nil {
-		return fmt.Errorf("db.ListParents failed: %w", err)
+		// Same as in refreshGroups, the role might have been removed
+		return nil
 	}
 
-	if false {
-		fmt.Println("listconnected", time.Since(a))
+	return d.refreshSubsets(ctx, tx, nestable, tuple.Subject, maps.Keys(inherited))
+}
+
+// refreshSubsets replaces the subsets of the sets of obj: the groups in them, and the same sets of obj's parents.
+func (d *Doorman) refreshSubsets(ctx context.Context, tx db.Tx, nestable map[string]bool, obj doorman.Object, sets []doorman.Set) error {
+	connectedSubjects, err := d.tuples.WithTx(tx).ListConnected(ctx, obj, true)
+	if err != nil {
+		return fmt.Errorf("db.ListParents failed: %w", err)
 	}
 
-	sets := []doorman.Set{}
+	// A group or subject set is only a subset of the sets the role it has on obj puts it in,
+	// and only if every group on the way inherits from the next one
+	roles := newRoleResolver(d.roles)
+	subsets := map[doorman.Set][]doorman.Membership{}
 	for _, path := range connectedSubjects {
-		p := path[len(path)-1]
-		if path.GroupsOnly() {
-			sets = append(sets, doorman.Set{Object: p.Object, Verb: "inherits"})
+		sub := path.Object()
+		if !nestable[sub.Type()] && !sub.IsSubjectSet() {
+			continue
+		}
+		connects, err := roles.Connects(ctx, nestable, path.Inverted(obj))
+		if err != nil {
+			return err
+		}
+		if !connects {
+			continue
+		}
+
+		sets, err := doorman.RoleSets(ctx, path[0].Role, obj, roles.Retrieve)
+		if err != nil {
+			continue
+		}
+
+		for _, set := range sets {
+			subsets[set] = append(subsets[set], doorman.Membership{
+				Set:        doorman.Set{Object: sub, Verb: "inherits"},
+				ExpiresAt:  path.ExpiresAt(),
+				Conditions: path.Conditions(),
+			})
 		}
 	}
 
-	role, err := d.roles.Retrieve(ctx, roleId)
+	// Only the parents' sets are added, as their subsets are followed when checking
+	links, err := d.tuples.WithTx(tx).ListParents(ctx, obj)
 	if err != nil {
-		// WHY not error? Because this might run after a role is removed (from rebuild cache)
-		return nil
+		return fmt.Errorf("db.ListParents failed: %w", err)
+	}
+	for _, link := range links {
+		inherited, err := inheritedSets(ctx, roles, link)
+		if err != nil {
+			continue
+		}
+		for set, parentSet := range inherited {
+			membership := doorman.Membership{Set: parentSet, ExpiresAt: link.ExpiresAt}
+			if link.Condition != "" {
+				membership.Conditions = []string{link.Condition}
+			}
+			subsets[set] = append(subsets[set], membership)
+		}
 	}
 
-	for _, verb := range role.Verbs {
-		if err := d.sets.UpdateSubsets(ctx, doorman.Set{Object: obj, Verb: verb}, sets); err != nil {
+	for _, set := range sets {
+		if err := d.sets.WithTx(tx).UpdateSubsets(ctx, set, subsets[set]); err != nil {
 			return fmt.Errorf("updateSubsets failed: %w", err)
 		}
 	}
@@ -491,30 +2516,58 @@ func (d *Doorman) refreshGroups(ctx context.Context, tx pgx.Tx, obj doorman.Obje
 	return nil
 }
 
-func (d *Doorman) refreshParents(ctx context.Context, tx pgx.Tx, obj doorman.Object) error {
+// inheritedSets maps the sets of the subject of a tuple with a parent role to the same sets of the object.
+// Denies flow the same way as the verbs they deny.
+func inheritedSets(ctx context.Context, roles roleResolver, tuple doorman.Tuple) (map[doorman.Set]doorman.Set, error) {
+	role, err := roles.Retrieve(ctx, tuple.Role)
+	if err != nil || !role.Parent {
+		return nil, err
+	}
+	verbs, err := doorman.ResolveVerbs(ctx, tuple.Role, roles.Retrieve)
+	if err != nil {
+		return nil, err
+	}
+
+	inherited := map[doorman.Set]doorman.Set{}
+	for _, verb := range verbs {
+		set, parentSet := doorman.Set{Object: tuple.Subject, Verb: verb}, doorman.Set{Object: tuple.Object, Verb: verb}
+		inherited[set] = parentSet
+		inherited[set.Denied()] = parentSet.Denied()
+	}
+	return inherited, nil
+}
+
+func (d *Doorman) refreshParents(ctx context.Context, tx db.Tx, obj doorman.Object) error {
 	parents, err := d.tuples.WithTx(tx).ListParents(ctx, obj)
 	if err != nil {
 		return fmt.Errorf("db.ListParents failed: %w", err)
 	}
 
-	sets := []doorman.Set{}
+	sets := []doorman.Membership{}
 	for _, tuple := range parents {
-		role, err := d.roles.Retrieve(ctx, tuple.Role)
+		roleSets, err := doorman.RoleSets(ctx, tuple.Role, tuple.Object, d.roles.Retrieve)
 		if err != nil {
 			// WHY not error? Because this might run after a role is removed (from rebuild cache)
 			return nil
 		}
-		for _, verb := range role.Verbs {
-			sets = append(sets, doorman.Set{Object: tuple.Object, Verb: verb})
+		for _, set := range roleSets {
+			membership := doorman.Membership{
+				Set:       set,
+				ExpiresAt: tuple.ExpiresAt,
+			}
+			if tuple.Condition != "" {
+				membership.Conditions = []string{tuple.Condition}
+			}
+			sets = append(sets, membership)
 		}
 	}
 
-	return d.sets.UpdateParents(ctx, obj, sets)
+	return d.sets.WithTx(tx).UpdateParents(ctx, obj, sets)
 }
 
-func (d *Doorman) revokeWithTx(ctx context.Context, tx pgx.Tx, request *pb.RevokeRequest) (*pb.RevokeResponse, error) {
+func (d *Doorman) revokeWithTx(ctx context.Context, tx db.Tx, request *pb.RevokeRequest) (*pb.RevokeResponse, error) {
 	if err := d.tuples.WithTx(tx).Lock(ctx); err != nil {
-		return nil, fmt.Errorf("tuples.Lock failed: %w, %w", err, tx.Rollback(ctx))
+		return nil, fmt.Errorf("tuples.Lock failed: %w", err)
 	}
 
 	tuple := doorman.Tuple{
@@ -524,39 +2577,230 @@ func (d *Doorman) revokeWithTx(ctx context.Context, tx pgx.Tx, request *pb.Revok
 	}
 
 	if err := d.tuples.WithTx(tx).Remove(ctx, tuple); err != nil {
-		if err := tx.Rollback(ctx); err != nil {
-			return nil, fmt.Errorf("rollback failed after failing to add tuple: %w", err)
-		}
 		return nil, err
 	}
 
-	payload, err := json.Marshal(tuple)
+	change, err := d.addChangeWithTx(ctx, tx, "REVOKED", tuple)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RevokeResponse{ConsistencyToken: change.ID}, nil
+}
+
+func (d *Doorman) addChangeWithTx(ctx context.Context, tx db.Tx, typ string, payload any) (doorman.Change, error) {
+	bs, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("json marshaling failed: %w", err)
+		return doorman.Change{}, fmt.Errorf("json marshaling failed: %w", err)
 	}
 
 	change := doorman.Change{
 		ID:        xid.New().String(),
-		Type:      "REVOKED",
-		Payload:   payload,
+		Type:      typ,
+		Payload:   bs,
 		CreatedAt: time.Now(),
 	}
 
+	if p, ok := PrincipalFromContext(ctx); ok {
+		change.Actor = string(p.Subject())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(ReasonMetadataKey); len(values) > 0 {
+		change.Reason = values[0]
+	}
+	if values := md.Get(RequestIDMetadataKey); len(values) > 0 {
+		change.RequestID = values[0]
+	}
+
 	if err := d.changes.WithTx(tx).Add(ctx, change); err != nil {
-		return nil, fmt.Errorf("adding change failed: %w", err)
+		return doorman.Change{}, fmt.Errorf("adding change failed: %w", err)
+	}
+
+	return change, nil
+}
+
+// NewDoorman returns the Doorman of the default tenant.
+func NewDoorman(store db.Store) *Doorman {
+	ts := &tenants{store: store, processing: make(chan bool, 1), m: map[string]*Doorman{}}
+	ts.m[""] = ts.newDoorman("")
+	return ts.m[""]
+}
+
+// TenantMetadataKey is the request metadata the tenant is taken from, the default one is used if it is not set.
+const TenantMetadataKey = "doorman-tenant"
+
+// ReasonMetadataKey is the request metadata the reason for the changes a write makes is taken from, e.g. a ticket id.
+const ReasonMetadataKey = "doorman-reason"
+
+// RequestIDMetadataKey is the request metadata the changes a write makes record the client request id from.
+const RequestIDMetadataKey = "x-request-id"
+
+var tenantPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// forTenant returns the Doorman of the tenant of the request.
+func (d *Doorman) forTenant(ctx context.Context) (*Doorman, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(TenantMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return d.tenants.get(ctx, "")
+	}
+
+	if !tenantPattern.MatchString(values[0]) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tenant %q, must match %s", values[0], tenantPattern)
+	}
+	return d.tenants.get(ctx, values[0])
+}
+
+// tenants holds the Doorman of each tenant that has been used.
+type tenants struct {
+	// of the default tenant
+	store db.Store
+	// shared by the tenants, as all of their changes are processed by the same loop
+	processing chan bool
+
+	// whether the writes check the principal may make them, see EnableAuthorization
+	authorize bool
+
+	mu sync.Mutex
+	m  map[string]*Doorman
+}
+
+func (ts *tenants) newDoorman(id string) *Doorman {
+	store := ts.store.Tenant(id)
+	return &Doorman{tenant: id, tenants: ts, processing: ts.processing, applied: newNotifier(), committed: newNotifier(), local: newAppliedChanges(), store: store, changes: store.Changes(), sets: db.NewSets(store.Sets()), roles: store.Roles(), tuples: store.Tuples(), types: store.Types(), objects: db.Objects{}}
+}
+
+// get returns the Doorman of the tenant, loading its cache if it is used for the first time.
+func (ts *tenants) get(ctx context.Context, id string) (*Doorman, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if d, ok := ts.m[id]; ok {
+		return d, nil
+	}
+
+	d := ts.newDoorman(id)
+	if err := d.sets.Load(ctx); err != nil {
+		return nil, fmt.Errorf("loading the cache of tenant %q failed: %w", id, err)
+	}
+	ts.m[id] = d
+
+	return d, nil
+}
+
+// all returns the tenants that have been used.
+func (ts *tenants) all() []*Doorman {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return maps.Values(ts.m)
+}
+
+// each calls fn with every tenant that has anything stored.
+func (ts *tenants) each(ctx context.Context, fn func(d *Doorman) error) error {
+	ids, err := ts.store.Tenants(ctx)
+	if err != nil {
+		return fmt.Errorf("listing tenants failed: %w", err)
+	}
+
+	for _, id := range ids {
+		d, err := ts.get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(d); err != nil {
+			return fmt.Errorf("tenant %q: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+func mapChangeToPb(c doorman.Change) (*pb.Change, error) {
+	res := &pb.Change{
+		Id:        c.ID,
+		Type:      c.Type,
+		Status:    c.Status,
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		Actor:     c.Actor,
+		Reason:    c.Reason,
+		RequestId: c.RequestID,
+	}
+
+	switch c.Type {
+	case "GRANTED", "REVOKED":
+		var tuple doorman.Tuple
+		if err := json.Unmarshal(c.Payload, &tuple); err != nil {
+			return nil, fmt.Errorf("json unmarshal failed: %w", err)
+		}
+		res.Payload = &pb.Change_Tuple{Tuple: mapTupleToPb(tuple)}
+	case "ROLE_UPSERTED", "ROLE_REMOVED":
+		var role doorman.Role
+		if err := json.Unmarshal(c.Payload, &role); err != nil {
+			return nil, fmt.Errorf("json unmarshal failed: %w", err)
+		}
+		res.Payload = &pb.Change_Role{Role: mapRoleToPb(role)}
+	case "TYPE_UPSERTED", "TYPE_REMOVED":
+		var typ doorman.Type
+		if err := json.Unmarshal(c.Payload, &typ); err != nil {
+			return nil, fmt.Errorf("json unmarshal failed: %w", err)
+		}
+		res.Payload = &pb.Change_ObjectType{ObjectType: mapTypeToPb(typ)}
+	}
+
+	return res, nil
+}
+
+func mapPathToPb(path doorman.Path) []*pb.Connection {
+	conns := make([]*pb.Connection, len(path))
+	for i, conn := range path {
+		conns[i] = &pb.Connection{
+			Role:      conn.Role,
+			Object:    string(conn.Object),
+			ExpiresAt: mapTimeToPb(conn.ExpiresAt),
+			Condition: conn.Condition,
+		}
+	}
+	return conns
+}
+
+func mapContextFromPb(context map[string]*structpb.Value) map[string]any {
+	m := make(map[string]any, len(context))
+	for k, v := range context {
+		m[k] = v.AsInterface()
 	}
+	return m
+}
 
-	return &pb.RevokeResponse{}, nil
+func mapTimeToPb(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
 }
 
-func NewDoorman(conn *pgxpool.Pool) *Doorman {
-	return &Doorman{processing: make(chan bool, 1), conn: conn, changes: db.NewChanges(conn), sets: db.NewSets(conn), roles: db.NewRoles(conn), tuples: db.NewTuples(conn), objects: db.NewObjects(conn)}
+func mapTupleToPb(t doorman.Tuple) *pb.Tuple {
+	return &pb.Tuple{
+		Subject:   string(t.Subject),
+		Role:      t.Role,
+		Object:    string(t.Object),
+		ExpiresAt: mapTimeToPb(t.ExpiresAt),
+		Condition: t.Condition,
+	}
 }
 
-func mapChangeToPb(c doorman.Change) *pb.Change {
-	return &pb.Change{
-		Type: c.Type,
+func mapTupleFromPb(t *pb.Tuple) doorman.Tuple {
+	tuple := doorman.NewTuple(doorman.Object(t.Subject), t.Role, doorman.Object(t.Object))
+	if t.ExpiresAt != nil {
+		expiresAt := t.ExpiresAt.AsTime()
+		tuple.ExpiresAt = &expiresAt
 	}
+	tuple.Condition = t.Condition
+	return tuple
+}
+
+func mapTypeToPb(t doorman.Type) *pb.Type {
+	return &pb.Type{Id: t.ID, Nestable: t.Nestable}
 }
 
 func mapRoleToPb(r doorman.Role) *pb.Role {
@@ -565,7 +2809,216 @@ func mapRoleToPb(r doorman.Role) *pb.Role {
 		verbs[i] = string(v)
 	}
 	return &pb.Role{
-		Id:    r.ID,
-		Verbs: verbs,
+		Id:       r.ID,
+		Verbs:    verbs,
+		Includes: r.Includes,
+		Deny:     r.Deny,
+		Parent:   r.Parent,
+	}
+}
+
+func mapRoleFromPb(r *pb.Role) doorman.Role {
+	verbs := make([]doorman.Verb, len(r.Verbs))
+	for i, v := range r.Verbs {
+		verbs[i] = doorman.Verb(v)
+	}
+	return doorman.Role{
+		ID:       r.Id,
+		Verbs:    verbs,
+		Includes: r.Includes,
+		Deny:     r.Deny,
+		Parent:   r.Parent,
+	}
+}
+
+// roleResolver retrieves each role at most once, as roles repeat a lot across paths.
+type roleResolver struct {
+	roles db.RoleStore
+	// nil if the role does not exist
+	cache map[string]*doorman.Role
+	// including the verbs of the roles it includes
+	verbs map[string][]doorman.Verb
+}
+
+func newRoleResolver(roles db.RoleStore) roleResolver {
+	return roleResolver{roles: roles, cache: map[string]*doorman.Role{}, verbs: map[string][]doorman.Verb{}}
+}
+
+// Retrieve fails with db.ErrInvalidRole if the role does not exist, e.g. if it has just been removed.
+func (r roleResolver) Retrieve(ctx context.Context, roleID string) (*doorman.Role, error) {
+	role, ok := r.cache[roleID]
+	if !ok {
+		var err error
+		role, err = r.roles.Retrieve(ctx, roleID)
+		if err == db.ErrInvalidRole {
+			role = nil
+		} else if err != nil {
+			return nil, fmt.Errorf("db.Retrieve failed: %w", err)
+		}
+		r.cache[roleID] = role
+	}
+
+	if role == nil {
+		return nil, db.ErrInvalidRole
+	}
+	return role, nil
+}
+
+// HasVerb also checks the roles the role includes. It is false for deny and parent roles, see Denies and Flows.
+func (r roleResolver) HasVerb(ctx context.Context, roleID string, verb doorman.Verb) (bool, error) {
+	return r.hasVerb(ctx, roleID, verb, func(role *doorman.Role) bool { return !role.Deny && !role.Parent })
+}
+
+// Connects checks if the subject of the path gets the role of its last connection.
+// Every object in between has to be nestable, e.g. a group, and the role on it has to inherit.
+// Subject sets and the objects they are of are the exception, their role is what makes them connect.
+func (r roleResolver) Connects(ctx context.Context, nestable map[string]bool, path doorman.Path) (bool, error) {
+	for i := 0; i < len(path)-1; i++ {
+		c := path[i]
+		if c.Object.IsSubjectSet() || path[i+1].Object.IsSubjectSet() {
+			continue
+		}
+		if !nestable[c.Object.Type()] {
+			return false, nil
+		}
+		inherits, err := r.HasVerb(ctx, c.Role, "inherits")
+		if err != nil || !inherits {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Denies checks if the role is a deny role that takes away the verb.
+func (r roleResolver) Denies(ctx context.Context, roleID string, verb doorman.Verb) (bool, error) {
+	return r.hasVerb(ctx, roleID, verb, func(role *doorman.Role) bool { return role.Deny })
+}
+
+// Flows checks if the role is a parent role the verb flows through, from the object to the child.
+func (r roleResolver) Flows(ctx context.Context, roleID string, verb doorman.Verb) (bool, error) {
+	return r.hasVerb(ctx, roleID, verb, func(role *doorman.Role) bool { return role.Parent })
+}
+
+// hasVerb is false if the role does not exist, or is not of the kind.
+func (r roleResolver) hasVerb(ctx context.Context, roleID string, verb doorman.Verb, kind func(role *doorman.Role) bool) (bool, error) {
+	verbs, ok := r.verbs[roleID]
+	if !ok {
+		var err error
+		verbs, err = doorman.ResolveVerbs(ctx, roleID, r.Retrieve)
+		if errors.Is(err, db.ErrInvalidRole) {
+			verbs = nil
+		} else if err != nil {
+			return false, err
+		}
+		r.verbs[roleID] = verbs
+	}
+
+	if verbs == nil || !kind(r.cache[roleID]) {
+		return false, nil
+	}
+	return slices.Contains(verbs, verb), nil
+}
+
+// notifier wakes up everyone waiting, every time notify is called.
+type notifier struct {
+	mu sync.Mutex
+	// closed and replaced on notify
+	ch chan struct{}
+}
+
+func newNotifier() *notifier {
+	return &notifier{ch: make(chan struct{})}
+}
+
+// wait returns a channel that is closed on the next notify.
+func (n *notifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.ch
+}
+
+func (n *notifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.ch)
+	n.ch = make(chan struct{})
+}
+
+// maxAppliedChanges bounds the ids kept by appliedChanges. Forgetting them only costs an extra reload.
+const maxAppliedChanges = 10_000
+
+// appliedChanges are the ids of the changes that have been applied to a cache.
+type appliedChanges struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+func newAppliedChanges() *appliedChanges {
+	return &appliedChanges{ids: map[string]struct{}{}}
+}
+
+func (a *appliedChanges) add(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.ids) >= maxAppliedChanges {
+		clear(a.ids)
+	}
+	a.ids[id] = struct{}{}
+}
+
+func (a *appliedChanges) contains(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, ok := a.ids[id]
+	return ok
+}
+
+// typeResolver checks each type at most once.
+type typeResolver struct {
+	types db.TypeStore
+	known map[string]bool
+}
+
+func newTypeResolver(types db.TypeStore) typeResolver {
+	return typeResolver{types: types, known: map[string]bool{}}
+}
+
+// nestableTypes lists the ids of the types whose members inherit their permissions.
+func nestableTypes(ctx context.Context, types db.TypeStore) (map[string]bool, error) {
+	all, err := types.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("types.List failed: %w", err)
+	}
+
+	nestable := map[string]bool{}
+	for _, t := range all {
+		if t.Nestable {
+			nestable[t.ID] = true
+		}
+	}
+	return nestable, nil
+}
+
+// Validate fails with InvalidArgument if any of the objects is malformed or of an unknown type.
+func (r typeResolver) Validate(ctx context.Context, objects ...doorman.Object) error {
+	for _, o := range objects {
+		if err := o.Validate(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "%q: %s", o, err)
+		}
+
+		known, ok := r.known[o.Type()]
+		if !ok {
+			_, err := r.types.Retrieve(ctx, o.Type())
+			if err != nil && err != db.ErrInvalidType {
+				return fmt.Errorf("db.Retrieve failed: %w", err)
+			}
+			known = err == nil
+			r.known[o.Type()] = known
+		}
+		if !known {
+			return status.Errorf(codes.InvalidArgument, "%q: unknown type %q", o, o.Type())
+		}
 	}
+
+	return nil
 }
@@ -3,15 +3,27 @@ package server
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/xid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/td0m/doorman"
 	"github.com/td0m/doorman/db"
 	pb "github.com/td0m/doorman/gen/go"
+	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func processAllChanges(srv *Doorman) {
@@ -50,14 +62,31 @@ func check(s *Doorman, sub doorman.Object, verb string, obj doorman.Object) *pb.
 	return res
 }
 
-var conn *pgxpool.Pool
+// openStore returns an empty store, in memory unless DOORMAN_TEST_STORE is set.
+var openStore = func() db.Store {
+	return db.NewMemory()
+}
+
+// newStore returns a store with the types used by the tests.
+func newStore() db.Store {
+	store := openStore()
+	for _, id := range []string{"user", "group", "groop", "item"} {
+		if err := store.Types().Upsert(context.Background(), doorman.Type{ID: id, Nestable: id == "group"}); err != nil {
+			panic(err)
+		}
+	}
+	return store
+}
 
 func cleanup(conn *pgxpool.Pool) {
 	ctx := context.Background()
 	_, err := conn.Exec(ctx, `
 		delete from tuples;
 		delete from roles;
+		delete from types;
 		delete from changes;
+		delete from set_parents;
+		delete from set_subsets;
 	`)
 
 	if err != nil {
@@ -67,19 +96,40 @@ func cleanup(conn *pgxpool.Pool) {
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
-	pool, err := pgxpool.New(ctx, "")
-	if err != nil {
-		panic(err)
+
+	switch os.Getenv("DOORMAN_TEST_STORE") {
+	case "", "memory":
+	case "postgres":
+		pool, err := pgxpool.New(ctx, "")
+		if err != nil {
+			panic(err)
+		}
+		openStore = func() db.Store {
+			cleanup(pool)
+			return db.NewPostgres(pool)
+		}
+	case "sqlite":
+		dir, err := os.MkdirTemp("", "doorman")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(dir)
+		openStore = func() db.Store {
+			store, err := db.OpenSQLite(ctx, filepath.Join(dir, xid.New().String()+".db"))
+			if err != nil {
+				panic(err)
+			}
+			return store
+		}
+	default:
+		panic("unknown DOORMAN_TEST_STORE: " + os.Getenv("DOORMAN_TEST_STORE"))
 	}
-	conn = pool
 
 	m.Run()
 }
 
 func TestCheckDirect(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -114,9 +164,7 @@ func TestCheckDirect(t *testing.T) {
 }
 
 func TestCheckViaGroup(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -165,9 +213,7 @@ func TestCheckViaGroup(t *testing.T) {
 }
 
 func TestCheckViaTwoGroups(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -225,9 +271,7 @@ func TestCheckViaTwoGroups(t *testing.T) {
 }
 
 func TestCheckViaThreeGroups(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -295,9 +339,7 @@ func TestCheckViaThreeGroups(t *testing.T) {
 }
 
 func TestCheckViaThreeGroupsGrantedInParallel(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -376,9 +418,7 @@ func TestCheckViaThreeGroupsGrantedInParallel(t *testing.T) {
 }
 
 func TestCheckViaGroop(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -427,9 +467,7 @@ func TestCheckViaGroop(t *testing.T) {
 }
 
 func TestCheckViaGroupAndGroop(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -487,9 +525,7 @@ func TestCheckViaGroupAndGroop(t *testing.T) {
 }
 
 func TestConnectingToSelfFails(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	groupMember := doorman.Role{ID: "group:member", Verbs: []doorman.Verb{"inherits"}}
@@ -532,9 +568,6 @@ func TestConnectingToSelfFails(t *testing.T) {
 }
 
 func TestConnectingToSelfIndirectlyInParallelFails(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
 	ctx := context.Background()
 
 	groupMember := doorman.Role{ID: "group:member", Verbs: []doorman.Verb{"inherits"}}
@@ -545,14 +578,14 @@ func TestConnectingToSelfIndirectlyInParallelFails(t *testing.T) {
 	}
 	banana := doorman.Object("item:banana")
 
-	require.NoError(t, s.objects.Add(ctx, admins))
-	require.NoError(t, s.objects.Add(ctx, banana))
-	require.NoError(t, s.roles.Add(ctx, groupMember))
-	require.NoError(t, s.roles.Add(ctx, owner))
-
 	// run many times as failing cound happen at random
 	for i := 0; i < 100; i++ {
-		_, _ = conn.Exec(ctx, `delete from tuples`)
+		s := NewDoorman(newStore())
+
+		require.NoError(t, s.objects.Add(ctx, admins))
+		require.NoError(t, s.objects.Add(ctx, banana))
+		require.NoError(t, s.roles.Add(ctx, groupMember))
+		require.NoError(t, s.roles.Add(ctx, owner))
 
 		var g errgroup.Group
 		g.Go(func() error {
@@ -578,9 +611,7 @@ func TestConnectingToSelfIndirectlyInParallelFails(t *testing.T) {
 }
 
 func TestCheckRevoke(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -631,9 +662,7 @@ func TestCheckRevoke(t *testing.T) {
 }
 
 func TestCheckRevokeOneOfTwo(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -687,9 +716,7 @@ func TestCheckRevokeOneOfTwo(t *testing.T) {
 }
 
 func TestCheckUpdateRole(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -734,9 +761,7 @@ func TestCheckUpdateRole(t *testing.T) {
 }
 
 func TestCheckUpsertRoleCreate(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -771,9 +796,7 @@ func TestCheckUpsertRoleCreate(t *testing.T) {
 }
 
 func TestRemoveRole(t *testing.T) {
-	cleanup(conn)
-
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -820,8 +843,7 @@ func TestRemoveRole(t *testing.T) {
 }
 
 // func TestListChanges(t *testing.T) {
-// 	cleanup(conn)
-// 	s := NewDoorman(conn)
+// 	s := NewDoorman(newStore())
 // 	ctx := context.Background()
 //
 // 	alice := doorman.Object("user:alice")
@@ -867,8 +889,7 @@ func TestRemoveRole(t *testing.T) {
 // }
 
 func TestRemoveOneOfTwoRolesWithSameVerb(t *testing.T) {
-	cleanup(conn)
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -931,8 +952,7 @@ func TestRemoveOneOfTwoRolesWithSameVerb(t *testing.T) {
 }
 
 func TestRemoveOneOfTwoRolesWithSameVerb2(t *testing.T) {
-	cleanup(conn)
-	s := NewDoorman(conn)
+	s := NewDoorman(newStore())
 	ctx := context.Background()
 
 	alice := doorman.Object("user:alice")
@@ -1000,3 +1020,1925 @@ func TestRemoveOneOfTwoRolesWithSameVerb2(t *testing.T) {
 		require.False(t, res.Success)
 	}
 }
+
+func TestCheckExplain(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	member := doorman.Role{ID: "group:member", Verbs: []doorman.Verb{"inherits"}}
+	superadmins := doorman.Object("group:superadmins")
+	admins := doorman.Object("group:admins")
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+	banana := doorman.Object("item:banana")
+
+	require.NoError(t, s.roles.Add(ctx, member))
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	grants := []*pb.GrantRequest{
+		{Subject: string(alice), Role: member.ID, Object: string(superadmins)},
+		{Subject: string(superadmins), Role: member.ID, Object: string(admins)},
+		{Subject: string(admins), Role: owner.ID, Object: string(banana)},
+		{Subject: string(bob), Role: owner.ID, Object: string(banana)},
+	}
+	for _, g := range grants {
+		_, err := s.Grant(ctx, g)
+		require.NoError(t, err)
+	}
+	processAllChanges(s)
+
+	t.Run("Via groups", func(t *testing.T) {
+		res, err := s.Check(ctx, &pb.CheckRequest{Subject: string(alice), Verb: "eat", Object: string(banana), Explain: true})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		require.Equal(t, 3, len(res.Path))
+		assert.Equal(t, string(superadmins), res.Path[0].Object)
+		assert.Equal(t, string(admins), res.Path[1].Object)
+		assert.Equal(t, string(banana), res.Path[2].Object)
+		assert.Equal(t, owner.ID, res.Path[2].Role)
+		assert.Equal(t, owner.ID, res.Role.Id)
+	})
+
+	t.Run("Direct", func(t *testing.T) {
+		res, err := s.Check(ctx, &pb.CheckRequest{Subject: string(bob), Verb: "eat", Object: string(banana), Explain: true})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		require.Equal(t, 1, len(res.Path))
+		assert.Equal(t, owner.ID, res.Path[0].Role)
+	})
+
+	t.Run("Nothing to explain if denied", func(t *testing.T) {
+		res, err := s.Check(ctx, &pb.CheckRequest{Subject: string(bob), Verb: "drink", Object: string(banana), Explain: true})
+		require.NoError(t, err)
+		require.False(t, res.Success)
+		assert.Nil(t, res.Role)
+		assert.Empty(t, res.Path)
+	})
+
+	t.Run("Not explained unless requested", func(t *testing.T) {
+		res, err := s.Check(ctx, &pb.CheckRequest{Subject: string(alice), Verb: "eat", Object: string(banana)})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+		assert.Nil(t, res.Role)
+	})
+}
+
+func TestBatchCheck(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+	banana := doorman.Object("item:banana")
+	apple := doorman.Object("item:apple")
+
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	_, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: owner.ID, Object: string(banana)})
+	require.NoError(t, err)
+	processAllChanges(s)
+
+	t.Run("Results are in order", func(t *testing.T) {
+		res, err := s.BatchCheck(ctx, &pb.BatchCheckRequest{Items: []*pb.CheckRequest{
+			{Subject: string(alice), Verb: "eat", Object: string(banana)},
+			{Subject: string(alice), Verb: "eat", Object: string(apple)},
+			{Subject: string(alice), Verb: "eat", Object: string(banana), Explain: true},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, 3, len(res.Items))
+
+		assert.True(t, res.Items[0].GetResponse().Success)
+		assert.False(t, res.Items[1].GetResponse().Success)
+		assert.True(t, res.Items[2].GetResponse().Success)
+		assert.Equal(t, owner.ID, res.Items[2].GetResponse().Role.Id)
+	})
+
+	t.Run("Fails if too many items", func(t *testing.T) {
+		items := make([]*pb.CheckRequest, maxBatchCheckItems+1)
+		for i := range items {
+			items[i] = &pb.CheckRequest{Subject: string(alice), Verb: "eat", Object: string(banana)}
+		}
+		_, err := s.BatchCheck(ctx, &pb.BatchCheckRequest{Items: items})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestListSubjects(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	carol := doorman.Object("user:carol")
+	dave := doorman.Object("user:dave")
+	admins := doorman.Object("group:admins")
+	banana := doorman.Object("item:banana")
+
+	member := doorman.Role{ID: "group:member", Verbs: []doorman.Verb{"inherits"}}
+	guest := doorman.Role{ID: "group:guest", Verbs: []doorman.Verb{"visit"}}
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+	require.NoError(t, s.roles.Add(ctx, member))
+	require.NoError(t, s.roles.Add(ctx, guest))
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	for _, req := range []*pb.GrantRequest{
+		{Subject: string(alice), Role: member.ID, Object: string(admins)},
+		{Subject: string(carol), Role: member.ID, Object: string(admins)},
+		{Subject: string(dave), Role: guest.ID, Object: string(admins)},
+		{Subject: string(admins), Role: owner.ID, Object: string(banana)},
+		{Subject: string(bob), Role: owner.ID, Object: string(banana)},
+	} {
+		_, err := s.Grant(ctx, req)
+		require.NoError(t, err)
+	}
+	processAllChanges(s)
+
+	subjects := func(res *pb.ListSubjectsResponse) []string {
+		out := make([]string, len(res.Items))
+		for i, item := range res.Items {
+			out[i] = item.Subject
+		}
+		return out
+	}
+
+	t.Run("Direct only", func(t *testing.T) {
+		res, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{string(admins), string(bob)}, subjects(res))
+		assert.Nil(t, res.PaginationToken)
+	})
+
+	t.Run("Transitive", func(t *testing.T) {
+		res, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat", Transitive: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{string(alice), string(bob), string(carol)}, subjects(res))
+	})
+
+	t.Run("Paginated", func(t *testing.T) {
+		size := int32(2)
+		res, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat", Transitive: true, PageSize: &size})
+		require.NoError(t, err)
+		assert.Equal(t, []string{string(alice), string(bob)}, subjects(res))
+		require.NotNil(t, res.PaginationToken)
+
+		res, err = s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat", Transitive: true, PageSize: &size, PaginationToken: res.PaginationToken})
+		require.NoError(t, err)
+		assert.Equal(t, []string{string(carol)}, subjects(res))
+		assert.Nil(t, res.PaginationToken)
+	})
+
+	t.Run("Paginated by a page at a time", func(t *testing.T) {
+		// dave is only listed by the store, as his role on admins doesn't inherit
+		size := int32(1)
+		var listed []string
+		req := &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat", Transitive: true, PageSize: &size}
+		for {
+			res, err := s.ListSubjects(ctx, req)
+			require.NoError(t, err)
+			listed = append(listed, subjects(res)...)
+			if res.PaginationToken == nil {
+				break
+			}
+			req.PaginationToken = res.PaginationToken
+		}
+		assert.Equal(t, []string{string(alice), string(bob), string(carol)}, listed)
+	})
+
+	t.Run("Paginated while writing", func(t *testing.T) {
+		size := int32(2)
+		res, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat", Transitive: true, PageSize: &size})
+		require.NoError(t, err)
+		assert.Equal(t, []string{string(alice), string(bob)}, subjects(res))
+
+		// Neither the subject removed from the first page nor the one added to it moves carol
+		_, err = s.Revoke(ctx, &pb.RevokeRequest{Subject: string(alice), Role: member.ID, Object: string(admins)})
+		require.NoError(t, err)
+		granted, err := s.Grant(ctx, &pb.GrantRequest{Subject: "user:aaron", Role: owner.ID, Object: string(banana)})
+		require.NoError(t, err)
+		processAllChanges(s)
+
+		res, err = s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat", Transitive: true, PageSize: &size, PaginationToken: res.PaginationToken, ConsistencyToken: &granted.ConsistencyToken})
+		require.NoError(t, err)
+		assert.Equal(t, []string{string(carol)}, subjects(res))
+		assert.Nil(t, res.PaginationToken)
+	})
+
+	t.Run("Fails if page size is invalid", func(t *testing.T) {
+		size := int32(maxPageSize + 1)
+		_, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat", PageSize: &size})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestCacheIsLoadedFromStore(t *testing.T) {
+	store := newStore()
+	s := NewDoorman(store)
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	admins := doorman.Object("group:admins")
+	banana := doorman.Object("item:banana")
+	member := doorman.Role{ID: "group:member", Verbs: []doorman.Verb{"inherits"}}
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+	require.NoError(t, s.roles.Add(ctx, member))
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	_, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: member.ID, Object: string(admins)})
+	require.NoError(t, err)
+	_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(admins), Role: owner.ID, Object: string(banana)})
+	require.NoError(t, err)
+	processAllChanges(s)
+
+	require.True(t, check(s, alice, "eat", banana).Success)
+
+	// A new instance does not see anything until the cache is loaded
+	s2 := NewDoorman(store)
+	require.False(t, check(s2, alice, "eat", banana).Success)
+
+	require.NoError(t, s2.LoadCache(ctx))
+	require.True(t, check(s2, alice, "eat", banana).Success)
+
+	_, err = s.Revoke(ctx, &pb.RevokeRequest{Subject: string(alice), Role: member.ID, Object: string(admins)})
+	require.NoError(t, err)
+	processAllChanges(s)
+
+	require.NoError(t, s2.LoadCache(ctx))
+	require.False(t, check(s2, alice, "eat", banana).Success)
+}
+
+func TestConsistencyToken(t *testing.T) {
+	store := newStore()
+	s := NewDoorman(store)
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+	banana := doorman.Object("item:banana")
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	res, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: owner.ID, Object: string(banana)})
+	require.NoError(t, err)
+	require.NotEmpty(t, res.ConsistencyToken)
+
+	request := &pb.CheckRequest{
+		Subject:          string(alice),
+		Verb:             "eat",
+		Object:           string(banana),
+		ConsistencyToken: &res.ConsistencyToken,
+	}
+
+	t.Run("Fails if not applied in time", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+		defer cancel()
+
+		_, err := s.Check(ctx, request)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("Waits until applied", func(t *testing.T) {
+		go processAllChanges(s)
+
+		checked, err := s.Check(ctx, request)
+		require.NoError(t, err)
+		assert.True(t, checked.Success)
+
+		objects, err := s.ListObjects(ctx, &pb.ListObjectsRequest{Subject: string(alice), ConsistencyToken: &res.ConsistencyToken})
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(objects.Items))
+	})
+
+	t.Run("Waits until applied by another instance", func(t *testing.T) {
+		other := NewDoorman(store)
+		apple := doorman.Object("item:apple")
+
+		res, err := other.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: owner.ID, Object: string(apple)})
+		require.NoError(t, err)
+		processAllChanges(other)
+
+		checked, err := s.Check(ctx, &pb.CheckRequest{Subject: string(alice), Verb: "eat", Object: string(apple), ConsistencyToken: &res.ConsistencyToken})
+		require.NoError(t, err)
+		assert.True(t, checked.Success)
+	})
+
+	t.Run("Fails if token is invalid", func(t *testing.T) {
+		token := "foo"
+		_, err := s.Check(ctx, &pb.CheckRequest{Subject: string(alice), Verb: "eat", Object: string(banana), ConsistencyToken: &token})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+type watchStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	changes chan *pb.Change
+}
+
+func (s watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s watchStream) Send(c *pb.Change) error {
+	s.changes <- c
+	return nil
+}
+
+func TestWatch(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+	banana := doorman.Object("item:banana")
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	first, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: owner.ID, Object: string(banana)})
+	require.NoError(t, err)
+
+	watch := func(after *string) (watchStream, func()) {
+		ctx, cancel := context.WithCancel(ctx)
+		stream := watchStream{ctx: ctx, changes: make(chan *pb.Change, 10)}
+		done := make(chan error)
+		go func() {
+			done <- s.Watch(&pb.WatchRequest{After: after}, stream)
+		}()
+		return stream, func() {
+			cancel()
+			require.NoError(t, <-done)
+		}
+	}
+
+	next := func(t *testing.T, stream watchStream) *pb.Change {
+		select {
+		case c := <-stream.changes:
+			return c
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for a change")
+			return nil
+		}
+	}
+
+	t.Run("Streams changes as they are committed", func(t *testing.T) {
+		stream, stop := watch(&first.ConsistencyToken)
+		defer stop()
+
+		res, err := s.Revoke(ctx, &pb.RevokeRequest{Subject: string(alice), Role: owner.ID, Object: string(banana)})
+		require.NoError(t, err)
+
+		c := next(t, stream)
+		assert.Equal(t, res.ConsistencyToken, c.Id)
+		assert.Equal(t, "REVOKED", c.Type)
+	})
+
+	t.Run("Resumes after change", func(t *testing.T) {
+		stream, stop := watch(&first.ConsistencyToken)
+		defer stop()
+
+		c := next(t, stream)
+		assert.Equal(t, "REVOKED", c.Type)
+	})
+
+	t.Run("Streams changes in the order they were committed, whatever their ids", func(t *testing.T) {
+		stream, stop := watch(&first.ConsistencyToken)
+		defer stop()
+
+		assert.Equal(t, "REVOKED", next(t, stream).Type)
+
+		// e.g. created by another instance just before the first one, but committed after
+		old := doorman.Change{ID: "0", Type: "GRANTED", Payload: []byte(`{"subject": "user:bob", "role": "item:owner", "object": "item:banana"}`)}
+		require.NoError(t, s.changes.Add(ctx, old))
+		s.committed.notify()
+
+		assert.Equal(t, old.ID, next(t, stream).Id)
+	})
+}
+
+func TestChanges(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	banana := doorman.Object("item:banana")
+
+	start := time.Now().Add(-time.Second)
+
+	_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:owner", Verbs: []string{"eat"}})
+	require.NoError(t, err)
+
+	for _, sub := range []doorman.Object{alice, bob} {
+		_, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(sub), Role: "item:owner", Object: string(banana)})
+		require.NoError(t, err)
+	}
+	_, err = s.Revoke(ctx, &pb.RevokeRequest{Subject: string(alice), Role: "item:owner", Object: string(banana)})
+	require.NoError(t, err)
+
+	types := func(res *pb.ChangesResponse) []string {
+		out := make([]string, len(res.Items))
+		for i, item := range res.Items {
+			out[i] = item.Type
+		}
+		return out
+	}
+
+	t.Run("Payloads", func(t *testing.T) {
+		res, err := s.Changes(ctx, &pb.ChangesRequest{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"ROLE_UPSERTED", "GRANTED", "GRANTED", "REVOKED"}, types(res))
+		assert.Nil(t, res.PaginationToken)
+
+		assert.Equal(t, []string{"eat"}, res.Items[0].GetRole().Verbs)
+		assert.Equal(t, string(alice), res.Items[3].GetTuple().Subject)
+		assert.Equal(t, "item:owner", res.Items[3].GetTuple().Role)
+		assert.Equal(t, string(banana), res.Items[3].GetTuple().Object)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		granted := "GRANTED"
+		res, err := s.Changes(ctx, &pb.ChangesRequest{Type: &granted})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GRANTED", "GRANTED"}, types(res))
+
+		processAllChanges(s)
+		_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: "item:owner", Object: string(banana)})
+		require.NoError(t, err)
+
+		pending := "pending"
+		res, err = s.Changes(ctx, &pb.ChangesRequest{Status: &pending})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GRANTED"}, types(res))
+
+		res, err = s.Changes(ctx, &pb.ChangesRequest{CreatedBefore: timestamppb.New(start)})
+		require.NoError(t, err)
+		assert.Empty(t, res.Items)
+
+		res, err = s.Changes(ctx, &pb.ChangesRequest{CreatedAfter: timestamppb.New(start), CreatedBefore: timestamppb.New(time.Now().Add(time.Second))})
+		require.NoError(t, err)
+		assert.Equal(t, 5, len(res.Items))
+	})
+
+	t.Run("Paginated", func(t *testing.T) {
+		size := int32(3)
+		res, err := s.Changes(ctx, &pb.ChangesRequest{PageSize: &size})
+		require.NoError(t, err)
+		assert.Equal(t, 3, len(res.Items))
+		require.NotNil(t, res.PaginationToken)
+
+		res, err = s.Changes(ctx, &pb.ChangesRequest{PageSize: &size, PaginationToken: res.PaginationToken})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"REVOKED", "GRANTED"}, types(res))
+		assert.Nil(t, res.PaginationToken)
+	})
+}
+
+func TestAudit(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	banana := doorman.Object("item:banana")
+	apple := doorman.Object("item:apple")
+
+	asAlice := metadata.NewIncomingContext(withPrincipal(ctx, &Principal{ID: "alice"}), metadata.Pairs(ReasonMetadataKey, "TICKET-1", RequestIDMetadataKey, "req1"))
+	asBob := withPrincipal(ctx, &Principal{ID: "bob"})
+
+	_, err := s.UpsertRole(asAlice, &pb.UpsertRoleRequest{Id: "item:owner", Verbs: []string{"eat"}})
+	require.NoError(t, err)
+	_, err = s.Grant(asAlice, &pb.GrantRequest{Subject: string(bob), Role: "item:owner", Object: string(banana)})
+	require.NoError(t, err)
+	_, err = s.Grant(asBob, &pb.GrantRequest{Subject: string(alice), Role: "item:owner", Object: string(apple)})
+	require.NoError(t, err)
+	_, err = s.Revoke(ctx, &pb.RevokeRequest{Subject: string(bob), Role: "item:owner", Object: string(banana)})
+	require.NoError(t, err)
+
+	describe := func(res *pb.AuditResponse) []string {
+		out := make([]string, len(res.Items))
+		for i, item := range res.Items {
+			out[i] = item.Type + " " + item.Actor
+		}
+		return out
+	}
+
+	t.Run("Records who made each change and why", func(t *testing.T) {
+		res, err := s.Audit(ctx, &pb.AuditRequest{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"ROLE_UPSERTED user:alice", "GRANTED user:alice", "GRANTED user:bob", "REVOKED "}, describe(res))
+
+		assert.Equal(t, "TICKET-1", res.Items[1].Reason)
+		assert.Equal(t, "req1", res.Items[1].RequestId)
+		assert.Empty(t, res.Items[2].Reason)
+		assert.Empty(t, res.Items[2].RequestId)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		actor := "user:alice"
+		res, err := s.Audit(ctx, &pb.AuditRequest{Actor: &actor})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ROLE_UPSERTED user:alice", "GRANTED user:alice"}, describe(res))
+
+		subject := string(bob)
+		res, err = s.Audit(ctx, &pb.AuditRequest{Subject: &subject})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GRANTED user:alice", "REVOKED "}, describe(res))
+
+		object := string(apple)
+		res, err = s.Audit(ctx, &pb.AuditRequest{Object: &object, Actor: &actor})
+		require.NoError(t, err)
+		assert.Empty(t, res.Items)
+
+		res, err = s.Audit(ctx, &pb.AuditRequest{CreatedBefore: timestamppb.New(time.Now().Add(-time.Minute))})
+		require.NoError(t, err)
+		assert.Empty(t, res.Items)
+	})
+
+	t.Run("Paginated", func(t *testing.T) {
+		size := int32(3)
+		res, err := s.Audit(ctx, &pb.AuditRequest{PageSize: &size})
+		require.NoError(t, err)
+		assert.Equal(t, 3, len(res.Items))
+		require.NotNil(t, res.PaginationToken)
+
+		res, err = s.Audit(ctx, &pb.AuditRequest{PageSize: &size, PaginationToken: res.PaginationToken})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"REVOKED "}, describe(res))
+		assert.Nil(t, res.PaginationToken)
+	})
+}
+
+func TestTypes(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	_, err := s.Grant(ctx, &pb.GrantRequest{Subject: "user:alice", Role: owner.ID, Object: "item:banana"})
+	require.NoError(t, err)
+
+	t.Run("Rejects malformed objects", func(t *testing.T) {
+		for _, o := range []string{"alice", "user:", ":alice", "User:alice", "user:al ice"} {
+			_, err := s.Grant(ctx, &pb.GrantRequest{Subject: o, Role: owner.ID, Object: "item:banana"})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), o)
+		}
+	})
+
+	t.Run("Rejects unknown types", func(t *testing.T) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{Subject: "usr:alice", Role: owner.ID, Object: "item:banana"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = s.Revoke(ctx, &pb.RevokeRequest{Subject: "user:alice", Role: owner.ID, Object: "itm:banana"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = s.Check(ctx, &pb.CheckRequest{Subject: "usr:alice", Verb: "eat", Object: "item:banana"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		res, err := s.BatchCheck(ctx, &pb.BatchCheckRequest{Items: []*pb.CheckRequest{
+			{Subject: "usr:alice", Verb: "eat", Object: "item:banana"},
+			{Subject: "user:alice", Verb: "eat", Object: "item:banana"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, int32(codes.InvalidArgument), res.Items[0].GetError().Code)
+		assert.NotNil(t, res.Items[1].GetResponse())
+	})
+
+	t.Run("Manage types", func(t *testing.T) {
+		_, err := s.UpsertType(ctx, &pb.UpsertTypeRequest{Id: "Post"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = s.UpsertType(ctx, &pb.UpsertTypeRequest{Id: "post"})
+		require.NoError(t, err)
+
+		res, err := s.ListTypes(ctx, &pb.ListTypesRequest{})
+		require.NoError(t, err)
+		ids := []string{}
+		for _, typ := range res.Items {
+			ids = append(ids, typ.Id)
+		}
+		assert.Equal(t, []string{"groop", "group", "item", "post", "user"}, ids)
+
+		_, err = s.RemoveType(ctx, &pb.RemoveTypeRequest{Id: "item"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		_, err = s.RemoveType(ctx, &pb.RemoveTypeRequest{Id: "post"})
+		require.NoError(t, err)
+
+		_, err = s.RemoveType(ctx, &pb.RemoveTypeRequest{Id: "post"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		_, err = s.Grant(ctx, &pb.GrantRequest{Subject: "user:alice", Role: owner.ID, Object: "post:1"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestExpiringGrants(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	admins := doorman.Object("group:admins")
+	banana := doorman.Object("item:banana")
+	member := doorman.Role{ID: "group:member", Verbs: []doorman.Verb{"inherits"}}
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+
+	require.NoError(t, s.roles.Add(ctx, member))
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	expiresAt := time.Now().Add(300 * time.Millisecond)
+
+	t.Run("Rejects expiry in the past", func(t *testing.T) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{
+			Subject:   string(bob),
+			Role:      owner.ID,
+			Object:    string(banana),
+			ExpiresAt: timestamppb.New(time.Now().Add(-time.Second)),
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Grant", func(t *testing.T) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{
+			Subject:   string(alice),
+			Role:      member.ID,
+			Object:    string(admins),
+			ExpiresAt: timestamppb.New(expiresAt),
+		})
+		require.NoError(t, err)
+
+		_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(admins), Role: owner.ID, Object: string(banana)})
+		require.NoError(t, err)
+
+		_, err = s.Grant(ctx, &pb.GrantRequest{
+			Subject:   string(bob),
+			Role:      owner.ID,
+			Object:    string(banana),
+			ExpiresAt: timestamppb.New(expiresAt),
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Success: Check before expiry", func(t *testing.T) {
+		assert.True(t, check(s, alice, "eat", banana).Success)
+		assert.True(t, check(s, bob, "eat", banana).Success)
+		assert.True(t, check(s, admins, "eat", banana).Success)
+	})
+
+	time.Sleep(time.Until(expiresAt) + 50*time.Millisecond)
+
+	t.Run("Failure: Check after expiry, before it is revoked", func(t *testing.T) {
+		assert.False(t, check(s, alice, "eat", banana).Success)
+		assert.False(t, check(s, bob, "eat", banana).Success)
+		assert.True(t, check(s, admins, "eat", banana).Success)
+
+		res, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat", Transitive: true})
+		require.NoError(t, err)
+		assert.Empty(t, res.Items)
+	})
+
+	t.Run("Revoke expired", func(t *testing.T) {
+		require.NoError(t, s.RevokeExpired(ctx))
+		processAllChanges(s)
+
+		revoked := "REVOKED"
+		res, err := s.Changes(ctx, &pb.ChangesRequest{Type: &revoked})
+		require.NoError(t, err)
+
+		subjects := []string{}
+		for _, c := range res.Items {
+			subjects = append(subjects, c.GetTuple().Subject)
+		}
+		assert.ElementsMatch(t, []string{string(alice), string(bob)}, subjects)
+
+		assert.False(t, check(s, alice, "eat", banana).Success)
+		assert.True(t, check(s, admins, "eat", banana).Success)
+
+		// Nothing left to revoke
+		require.NoError(t, s.RevokeExpired(ctx))
+		tuples, err := s.tuples.ListExpiredTuples(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, tuples)
+	})
+}
+
+func TestConditionalGrants(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	admins := doorman.Object("group:admins")
+	banana := doorman.Object("item:banana")
+	member := doorman.Role{ID: "group:member", Verbs: []doorman.Verb{"inherits"}}
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+
+	require.NoError(t, s.roles.Add(ctx, member))
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	checkWith := func(s *Doorman, sub doorman.Object, context map[string]any) (*pb.CheckResponse, error) {
+		processAllChanges(s)
+		checkContext, err := structpb.NewStruct(context)
+		require.NoError(t, err)
+		return s.Check(ctx, &pb.CheckRequest{
+			Subject: string(sub),
+			Verb:    "eat",
+			Object:  string(banana),
+			Explain: true,
+			Context: checkContext.Fields,
+		})
+	}
+
+	t.Run("Rejects invalid conditions", func(t *testing.T) {
+		for _, c := range []string{"mfa &&", "amount <", "unknown(ip)", "in_cidr(ip)", `"unterminated`} {
+			_, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: owner.ID, Object: string(banana), Condition: c})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), c)
+		}
+	})
+
+	t.Run("Grant", func(t *testing.T) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{
+			Subject:   string(alice),
+			Role:      owner.ID,
+			Object:    string(banana),
+			Condition: "mfa && amount <= 100",
+		})
+		require.NoError(t, err)
+
+		_, err = s.Grant(ctx, &pb.GrantRequest{
+			Subject:   string(bob),
+			Role:      member.ID,
+			Object:    string(admins),
+			Condition: `in_cidr(ip, "10.0.0.0/8")`,
+		})
+		require.NoError(t, err)
+
+		_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(admins), Role: owner.ID, Object: string(banana)})
+		require.NoError(t, err)
+	})
+
+	t.Run("Missing context", func(t *testing.T) {
+		res, err := checkWith(s, alice, nil)
+		require.NoError(t, err)
+		assert.Equal(t, pb.Decision_MISSING_CONTEXT, res.Decision)
+		assert.Equal(t, []string{"amount", "mfa"}, res.MissingContext)
+		assert.False(t, res.Success)
+
+		res, err = checkWith(s, bob, map[string]any{"mfa": true})
+		require.NoError(t, err)
+		assert.Equal(t, pb.Decision_MISSING_CONTEXT, res.Decision)
+		assert.Equal(t, []string{"ip"}, res.MissingContext)
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		res, err := checkWith(s, alice, map[string]any{"mfa": true, "amount": 50})
+		require.NoError(t, err)
+		assert.Equal(t, pb.Decision_ALLOWED, res.Decision)
+		assert.True(t, res.Success)
+		require.Len(t, res.Path, 1)
+		assert.Equal(t, "mfa && amount <= 100", res.Path[0].Condition)
+
+		res, err = checkWith(s, bob, map[string]any{"ip": "10.1.2.3"})
+		require.NoError(t, err)
+		assert.Equal(t, pb.Decision_ALLOWED, res.Decision)
+		assert.Len(t, res.Path, 2)
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		res, err := checkWith(s, alice, map[string]any{"mfa": true, "amount": 500})
+		require.NoError(t, err)
+		assert.Equal(t, pb.Decision_DENIED, res.Decision)
+		assert.Empty(t, res.MissingContext)
+
+		res, err = checkWith(s, bob, map[string]any{"ip": "192.168.0.1"})
+		require.NoError(t, err)
+		assert.Equal(t, pb.Decision_DENIED, res.Decision)
+
+		_, err = checkWith(s, alice, map[string]any{"mfa": true, "amount": "lots"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Unconditional grants are still allowed without context", func(t *testing.T) {
+		res, err := checkWith(s, admins, nil)
+		require.NoError(t, err)
+		assert.Equal(t, pb.Decision_ALLOWED, res.Decision)
+	})
+
+	t.Run("Conditions are loaded from the store", func(t *testing.T) {
+		loaded := NewDoorman(s.store)
+		require.NoError(t, loaded.LoadCache(ctx))
+
+		res, err := checkWith(loaded, bob, map[string]any{"ip": "10.1.2.3"})
+		require.NoError(t, err)
+		assert.Equal(t, pb.Decision_ALLOWED, res.Decision)
+
+		res, err = checkWith(loaded, bob, nil)
+		require.NoError(t, err)
+		assert.Equal(t, pb.Decision_MISSING_CONTEXT, res.Decision)
+	})
+}
+
+func TestWildcardSubjects(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	everyone := doorman.Object("group:everyone")
+	post := doorman.Object("item:post")
+	banana := doorman.Object("item:banana")
+	member := doorman.Role{ID: "group:member", Verbs: []doorman.Verb{"inherits"}}
+	viewer := doorman.Role{ID: "item:viewer", Verbs: []doorman.Verb{"read"}}
+	owner := doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}
+
+	require.NoError(t, s.roles.Add(ctx, member))
+	require.NoError(t, s.roles.Add(ctx, viewer))
+	require.NoError(t, s.roles.Add(ctx, owner))
+
+	t.Run("Rejects wildcard objects", func(t *testing.T) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: viewer.ID, Object: "item:*"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Grant", func(t *testing.T) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{Subject: "user:*", Role: viewer.ID, Object: string(post)})
+		require.NoError(t, err)
+
+		_, err = s.Grant(ctx, &pb.GrantRequest{Subject: "user:*", Role: member.ID, Object: string(everyone)})
+		require.NoError(t, err)
+
+		_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(everyone), Role: owner.ID, Object: string(banana)})
+		require.NoError(t, err)
+	})
+
+	t.Run("Success: Check any user", func(t *testing.T) {
+		processAllChanges(s)
+		res, err := s.Check(ctx, &pb.CheckRequest{Subject: string(alice), Verb: "read", Object: string(post), Explain: true})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+		require.Len(t, res.Path, 2)
+		assert.Equal(t, "user:*", res.Path[0].Object)
+		assert.Equal(t, string(post), res.Path[1].Object)
+
+		assert.True(t, check(s, "user:bob", "read", post).Success)
+		assert.True(t, check(s, alice, "eat", banana).Success)
+	})
+
+	t.Run("Failure: Check other types", func(t *testing.T) {
+		assert.False(t, check(s, "group:admins", "read", post).Success)
+		assert.False(t, check(s, alice, "eat", post).Success)
+	})
+
+	t.Run("ListObjects includes wildcard grants", func(t *testing.T) {
+		res, err := s.ListObjects(ctx, &pb.ListObjectsRequest{Subject: string(alice)})
+		require.NoError(t, err)
+
+		objects := []string{}
+		for _, r := range res.Items {
+			objects = append(objects, r.Object+"#"+r.Verb)
+		}
+		assert.ElementsMatch(t, []string{"item:post#read", "group:everyone#inherits"}, objects)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		_, err := s.Revoke(ctx, &pb.RevokeRequest{Subject: "user:*", Role: viewer.ID, Object: string(post)})
+		require.NoError(t, err)
+
+		assert.False(t, check(s, alice, "read", post).Success)
+		assert.True(t, check(s, alice, "eat", banana).Success)
+	})
+}
+
+func TestRoleInheritance(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	post := doorman.Object("item:post")
+
+	_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"read"}})
+	require.NoError(t, err)
+	_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:editor", Verbs: []string{"write"}, Includes: []string{"item:viewer"}})
+	require.NoError(t, err)
+	_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:owner", Verbs: []string{"delete"}, Includes: []string{"item:editor"}})
+	require.NoError(t, err)
+
+	_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: "item:owner", Object: string(post)})
+	require.NoError(t, err)
+	_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(bob), Role: "item:viewer", Object: string(post)})
+	require.NoError(t, err)
+
+	t.Run("Success: included verbs", func(t *testing.T) {
+		assert.True(t, check(s, alice, "read", post).Success)
+		assert.True(t, check(s, alice, "write", post).Success)
+		assert.True(t, check(s, alice, "delete", post).Success)
+		assert.True(t, check(s, bob, "read", post).Success)
+	})
+
+	t.Run("Failure: roles don't get the verbs of roles including them", func(t *testing.T) {
+		assert.False(t, check(s, bob, "write", post).Success)
+	})
+
+	t.Run("Updating an included role updates the roles including it", func(t *testing.T) {
+		_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"read", "comment"}})
+		require.NoError(t, err)
+
+		assert.True(t, check(s, alice, "comment", post).Success)
+		assert.True(t, check(s, bob, "comment", post).Success)
+	})
+
+	t.Run("Failure: cycles", func(t *testing.T) {
+		_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"read"}, Includes: []string{"item:owner"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"read"}, Includes: []string{"item:viewer"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		assert.True(t, check(s, bob, "comment", post).Success)
+	})
+
+	t.Run("Failure: including a role that doesn't exist", func(t *testing.T) {
+		_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:admin", Verbs: []string{"read"}, Includes: []string{"item:foo"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Failure: removing an included role", func(t *testing.T) {
+		_, err := s.RemoveRole(ctx, &pb.RemoveRoleRequest{Id: "item:editor"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("Removing a verb from a role takes it away through groups too", func(t *testing.T) {
+		carol := doorman.Object("user:carol")
+		eng := doorman.Object("group:eng")
+
+		_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "group:member", Verbs: []string{"inherits"}})
+		require.NoError(t, err)
+		_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(carol), Role: "group:member", Object: string(eng)})
+		require.NoError(t, err)
+		_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(eng), Role: "item:editor", Object: string(post)})
+		require.NoError(t, err)
+		assert.True(t, check(s, carol, "comment", post).Success)
+
+		_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"read"}})
+		require.NoError(t, err)
+
+		assert.False(t, check(s, carol, "comment", post).Success)
+		assert.False(t, check(s, alice, "comment", post).Success)
+		assert.True(t, check(s, carol, "read", post).Success)
+		assert.True(t, check(s, carol, "write", post).Success)
+	})
+}
+
+func TestDenyRoles(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	carol := doorman.Object("user:carol")
+	dave := doorman.Object("user:dave")
+	eng := doorman.Object("group:eng")
+	contractors := doorman.Object("group:contractors")
+	prod := doorman.Object("item:prod")
+	secret := doorman.Object("item:secret")
+
+	_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "group:member", Verbs: []string{"inherits"}})
+	require.NoError(t, err)
+	_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:deployer", Verbs: []string{"deploy", "read"}})
+	require.NoError(t, err)
+	_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:blocked", Verbs: []string{"deploy"}, Deny: true})
+	require.NoError(t, err)
+
+	grant := func(sub doorman.Object, role string, obj doorman.Object, condition string) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(sub), Role: role, Object: string(obj), Condition: condition})
+		require.NoError(t, err)
+	}
+	for _, sub := range []doorman.Object{alice, bob, carol, dave} {
+		grant(sub, "group:member", eng, "")
+	}
+	grant(carol, "group:member", contractors, "")
+	grant(eng, "item:deployer", prod, "")
+	grant(bob, "item:deployer", prod, "")
+	grant(bob, "item:blocked", prod, "")
+	grant(contractors, "item:blocked", prod, "")
+	grant(dave, "item:blocked", prod, "!vpn")
+
+	t.Run("Success: only the denied verbs are taken away", func(t *testing.T) {
+		assert.True(t, check(s, alice, "deploy", prod).Success)
+		assert.True(t, check(s, bob, "read", prod).Success)
+		assert.True(t, check(s, carol, "read", prod).Success)
+	})
+
+	t.Run("Failure: denies override grants", func(t *testing.T) {
+		assert.False(t, check(s, bob, "deploy", prod).Success)
+	})
+
+	t.Run("Failure: denies are inherited through groups", func(t *testing.T) {
+		assert.False(t, check(s, carol, "deploy", prod).Success)
+	})
+
+	t.Run("Conditional denies only apply if they hold", func(t *testing.T) {
+		checkWith := func(context map[string]any) *pb.CheckResponse {
+			processAllChanges(s)
+			checkContext, err := structpb.NewStruct(context)
+			require.NoError(t, err)
+			res, err := s.Check(ctx, &pb.CheckRequest{Subject: string(dave), Verb: "deploy", Object: string(prod), Context: checkContext.Fields})
+			require.NoError(t, err)
+			return res
+		}
+
+		res := checkWith(nil)
+		assert.Equal(t, pb.Decision_MISSING_CONTEXT, res.Decision)
+		assert.Equal(t, []string{"vpn"}, res.MissingContext)
+
+		assert.Equal(t, pb.Decision_ALLOWED, checkWith(map[string]any{"vpn": true}).Decision)
+		assert.Equal(t, pb.Decision_DENIED, checkWith(map[string]any{"vpn": false}).Decision)
+	})
+
+	t.Run("Failure: wildcard denies", func(t *testing.T) {
+		grant(alice, "item:deployer", secret, "")
+		grant("user:*", "item:blocked", secret, "")
+		assert.False(t, check(s, alice, "deploy", secret).Success)
+		assert.True(t, check(s, alice, "read", secret).Success)
+	})
+
+	t.Run("ListObjects omits denied sets", func(t *testing.T) {
+		res, err := s.ListObjects(ctx, &pb.ListObjectsRequest{Subject: string(bob)})
+		require.NoError(t, err)
+
+		objects := []string{}
+		for _, r := range res.Items {
+			objects = append(objects, r.Object+"#"+r.Verb)
+		}
+		assert.ElementsMatch(t, []string{"group:eng#inherits", "item:prod#read"}, objects)
+	})
+
+	t.Run("ListSubjects omits denied subjects", func(t *testing.T) {
+		res, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(prod), Verb: "deploy", Transitive: true})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		assert.Equal(t, string(alice), res.Items[0].Subject)
+	})
+
+	t.Run("Revoking the deny", func(t *testing.T) {
+		_, err := s.Revoke(ctx, &pb.RevokeRequest{Subject: string(bob), Role: "item:blocked", Object: string(prod)})
+		require.NoError(t, err)
+		assert.True(t, check(s, bob, "deploy", prod).Success)
+	})
+
+	t.Run("Failure: invalid deny roles", func(t *testing.T) {
+		_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "group:banned", Verbs: []string{"inherits"}, Deny: true})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:suspended", Verbs: []string{"read"}, Includes: []string{"item:deployer"}, Deny: true})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:blocked", Verbs: []string{"!deploy"}, Deny: true})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestNestableTypes(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	for _, id := range []string{"team", "org"} {
+		_, err := s.UpsertType(ctx, &pb.UpsertTypeRequest{Id: id, Nestable: true})
+		require.NoError(t, err)
+	}
+
+	alice := doorman.Object("user:alice")
+	core := doorman.Object("team:core")
+	acme := doorman.Object("org:acme")
+	banana := doorman.Object("item:banana")
+
+	require.NoError(t, s.roles.Add(ctx, doorman.Role{ID: "team:member", Verbs: []doorman.Verb{"inherits"}}))
+	require.NoError(t, s.roles.Add(ctx, doorman.Role{ID: "org:member", Verbs: []doorman.Verb{"inherits"}}))
+	require.NoError(t, s.roles.Add(ctx, doorman.Role{ID: "item:owner", Verbs: []doorman.Verb{"eat"}}))
+
+	t.Run("Grant", func(t *testing.T) {
+		for _, tuple := range [][3]string{
+			{string(alice), "team:member", string(core)},
+			{string(core), "org:member", string(acme)},
+			{string(acme), "item:owner", string(banana)},
+		} {
+			_, err := s.Grant(ctx, &pb.GrantRequest{Subject: tuple[0], Role: tuple[1], Object: tuple[2]})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Success: Check through nested teams and orgs", func(t *testing.T) {
+		res := check(s, alice, "eat", banana)
+		assert.True(t, res.Success)
+
+		res, err := s.Check(ctx, &pb.CheckRequest{Subject: string(alice), Verb: "eat", Object: string(banana), Explain: true})
+		require.NoError(t, err)
+		assert.Len(t, res.Path, 3)
+	})
+
+	t.Run("ListSubjects only lists the members", func(t *testing.T) {
+		res, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(banana), Verb: "eat", Transitive: true})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		assert.Equal(t, string(alice), res.Items[0].Subject)
+	})
+
+	t.Run("ListTypes", func(t *testing.T) {
+		res, err := s.ListTypes(ctx, &pb.ListTypesRequest{})
+		require.NoError(t, err)
+		nestable := []string{}
+		for _, typ := range res.Items {
+			if typ.Nestable {
+				nestable = append(nestable, typ.Id)
+			}
+		}
+		assert.Equal(t, []string{"group", "org", "team"}, nestable)
+	})
+
+	t.Run("Failure: changing a type that is in use", func(t *testing.T) {
+		_, err := s.UpsertType(ctx, &pb.UpsertTypeRequest{Id: "team"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		_, err = s.UpsertType(ctx, &pb.UpsertTypeRequest{Id: "team", Nestable: true})
+		require.NoError(t, err)
+	})
+
+	t.Run("Groups are nestable in a new store", func(t *testing.T) {
+		s := NewDoorman(openStore())
+
+		typ, err := s.types.Retrieve(ctx, "group")
+		require.NoError(t, err)
+		assert.True(t, typ.Nestable)
+	})
+}
+
+func TestParentRoles(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	for _, id := range []string{"folder", "document"} {
+		_, err := s.UpsertType(ctx, &pb.UpsertTypeRequest{Id: id})
+		require.NoError(t, err)
+	}
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	carol := doorman.Object("user:carol")
+	dave := doorman.Object("user:dave")
+	eng := doorman.Object("group:eng")
+	root := doorman.Object("folder:root")
+	docs := doorman.Object("folder:docs")
+	readme := doorman.Object("document:readme")
+
+	for _, r := range []*pb.UpsertRoleRequest{
+		{Id: "group:member", Verbs: []string{"inherits"}},
+		{Id: "folder:viewer", Verbs: []string{"read"}},
+		{Id: "folder:editor", Verbs: []string{"write"}, Includes: []string{"folder:viewer"}},
+		{Id: "folder:blocked", Verbs: []string{"read"}, Deny: true},
+		{Id: "document:viewer", Verbs: []string{"read"}},
+		{Id: "folder:parent", Verbs: []string{"read"}, Parent: true},
+	} {
+		_, err := s.UpsertRole(ctx, r)
+		require.NoError(t, err)
+	}
+
+	grant := func(sub doorman.Object, role string, obj doorman.Object) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(sub), Role: role, Object: string(obj)})
+		require.NoError(t, err)
+	}
+	grant(readme, "folder:parent", docs)
+	grant(docs, "folder:parent", root)
+	grant(alice, "folder:viewer", docs)
+	grant(bob, "folder:editor", docs)
+	grant(carol, "group:member", eng)
+	grant(eng, "folder:viewer", root)
+	grant(dave, "document:viewer", readme)
+	grant(dave, "folder:blocked", root)
+
+	t.Run("Success: verbs flow to children", func(t *testing.T) {
+		assert.True(t, check(s, alice, "read", readme).Success)
+		assert.True(t, check(s, bob, "read", readme).Success)
+		assert.True(t, check(s, carol, "read", docs).Success)
+		assert.True(t, check(s, carol, "read", readme).Success)
+	})
+
+	t.Run("Failure: other verbs don't flow", func(t *testing.T) {
+		assert.True(t, check(s, bob, "write", docs).Success)
+		assert.False(t, check(s, bob, "write", readme).Success)
+	})
+
+	t.Run("Failure: children don't get the verbs themselves", func(t *testing.T) {
+		assert.False(t, check(s, readme, "read", docs).Success)
+	})
+
+	t.Run("Failure: denies flow too", func(t *testing.T) {
+		assert.False(t, check(s, dave, "read", readme).Success)
+	})
+
+	t.Run("Explain", func(t *testing.T) {
+		res, err := s.Check(ctx, &pb.CheckRequest{Subject: string(carol), Verb: "read", Object: string(readme), Explain: true})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		objects := []string{}
+		for _, conn := range res.Path {
+			objects = append(objects, conn.Object)
+		}
+		assert.Equal(t, []string{string(eng), string(root), string(docs), string(readme)}, objects)
+		assert.Equal(t, "folder:viewer", res.Role.Id)
+	})
+
+	t.Run("ListSubjects includes the subjects of parents", func(t *testing.T) {
+		res, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(readme), Verb: "read", Transitive: true})
+		require.NoError(t, err)
+
+		subjects := []string{}
+		for _, r := range res.Items {
+			subjects = append(subjects, r.Subject)
+		}
+		assert.Equal(t, []string{string(alice), string(bob), string(carol)}, subjects)
+	})
+
+	t.Run("Moving a child", func(t *testing.T) {
+		_, err := s.Revoke(ctx, &pb.RevokeRequest{Subject: string(readme), Role: "folder:parent", Object: string(docs)})
+		require.NoError(t, err)
+		assert.False(t, check(s, alice, "read", readme).Success)
+		assert.False(t, check(s, carol, "read", readme).Success)
+
+		grant(readme, "folder:parent", root)
+		assert.False(t, check(s, alice, "read", readme).Success)
+		assert.True(t, check(s, carol, "read", readme).Success)
+	})
+
+	t.Run("Failure: invalid parent roles", func(t *testing.T) {
+		_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "folder:hidden", Verbs: []string{"read"}, Parent: true, Deny: true})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "folder:member", Verbs: []string{"inherits"}, Parent: true})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "folder:owner", Verbs: []string{"delete"}, Includes: []string{"folder:parent"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestSubjectSets(t *testing.T) {
+	s := NewDoorman(newStore())
+	ctx := context.Background()
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	carol := doorman.Object("user:carol")
+	eng := doorman.Object("group:eng")
+	sre := doorman.Object("group:sre")
+	maintainers := doorman.Object("group:eng#maintainer")
+	api := doorman.Object("item:api")
+
+	for _, r := range []*pb.UpsertRoleRequest{
+		{Id: "group:member", Verbs: []string{"inherits"}},
+		{Id: "group:maintainer", Verbs: []string{"merge"}},
+		{Id: "item:deployer", Verbs: []string{"deploy"}},
+		{Id: "item:blocked", Verbs: []string{"deploy"}, Deny: true},
+	} {
+		_, err := s.UpsertRole(ctx, r)
+		require.NoError(t, err)
+	}
+
+	grant := func(sub doorman.Object, role string, obj doorman.Object) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(sub), Role: role, Object: string(obj)})
+		require.NoError(t, err)
+	}
+	grant(alice, "group:maintainer", eng)
+	grant(bob, "group:member", eng)
+	grant(carol, "group:member", sre)
+	grant(maintainers, "item:deployer", api)
+
+	t.Run("Success: holders of the role", func(t *testing.T) {
+		assert.True(t, check(s, alice, "deploy", api).Success)
+	})
+
+	t.Run("Failure: other members", func(t *testing.T) {
+		assert.False(t, check(s, bob, "deploy", api).Success)
+	})
+
+	t.Run("Success: holders of the role through a group", func(t *testing.T) {
+		assert.False(t, check(s, carol, "deploy", api).Success)
+		grant(sre, "group:maintainer", eng)
+		assert.True(t, check(s, carol, "deploy", api).Success)
+	})
+
+	t.Run("Explain", func(t *testing.T) {
+		res, err := s.Check(ctx, &pb.CheckRequest{Subject: string(carol), Verb: "deploy", Object: string(api), Explain: true})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		objects := []string{}
+		for _, conn := range res.Path {
+			objects = append(objects, conn.Object)
+		}
+		assert.Equal(t, []string{string(sre), string(eng), string(maintainers), string(api)}, objects)
+		assert.Equal(t, "item:deployer", res.Role.Id)
+	})
+
+	t.Run("ListSubjects", func(t *testing.T) {
+		for transitive, expected := range map[bool][]string{
+			false: {string(maintainers)},
+			true:  {string(alice), string(carol)},
+		} {
+			res, err := s.ListSubjects(ctx, &pb.ListSubjectsRequest{Object: string(api), Verb: "deploy", Transitive: transitive})
+			require.NoError(t, err)
+
+			subjects := []string{}
+			for _, r := range res.Items {
+				subjects = append(subjects, r.Subject)
+			}
+			assert.Equal(t, expected, subjects)
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		_, err := s.Revoke(ctx, &pb.RevokeRequest{Subject: string(sre), Role: "group:maintainer", Object: string(eng)})
+		require.NoError(t, err)
+		assert.False(t, check(s, carol, "deploy", api).Success)
+		assert.True(t, check(s, alice, "deploy", api).Success)
+
+		_, err = s.Revoke(ctx, &pb.RevokeRequest{Subject: string(alice), Role: "group:maintainer", Object: string(eng)})
+		require.NoError(t, err)
+		assert.False(t, check(s, alice, "deploy", api).Success)
+	})
+
+	t.Run("Failure: cycle through a subject set", func(t *testing.T) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(maintainers), Role: "group:maintainer", Object: string(eng)})
+		require.ErrorIs(t, err, db.ErrCycle)
+	})
+
+	t.Run("Failure: invalid subject sets", func(t *testing.T) {
+		for _, req := range []*pb.GrantRequest{
+			{Subject: string(alice), Role: "item:deployer", Object: "item:api#deployer"},
+			{Subject: "group:eng#owner", Role: "item:deployer", Object: string(api)},
+			{Subject: "item:web#blocked", Role: "item:deployer", Object: string(api)},
+			{Subject: "group:eng#", Role: "item:deployer", Object: string(api)},
+			{Subject: "group:*#maintainer", Role: "item:deployer", Object: string(api)},
+		} {
+			_, err := s.Grant(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), req.Subject)
+		}
+	})
+}
+
+type exportStream struct {
+	grpc.ServerStream
+	records []*pb.Record
+}
+
+func (s *exportStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *exportStream) Send(r *pb.Record) error {
+	s.records = append(s.records, r)
+	return nil
+}
+
+type importStream struct {
+	grpc.ServerStream
+	requests []*pb.ImportRequest
+	res      *pb.ImportResponse
+}
+
+func (s *importStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *importStream) Recv() (*pb.ImportRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *importStream) SendAndClose(res *pb.ImportResponse) error {
+	s.res = res
+	return nil
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := NewDoorman(newStore())
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	eng := doorman.Object("group:eng")
+	api := doorman.Object("item:api")
+
+	for _, r := range []*pb.UpsertRoleRequest{
+		{Id: "group:member", Verbs: []string{"inherits"}},
+		{Id: "group:admin", Verbs: []string{"manage"}, Includes: []string{"group:member"}},
+		{Id: "item:deployer", Verbs: []string{"deploy"}},
+	} {
+		_, err := src.UpsertRole(ctx, r)
+		require.NoError(t, err)
+	}
+	for _, req := range []*pb.GrantRequest{
+		{Subject: string(alice), Role: "group:admin", Object: string(eng)},
+		{Subject: string(bob), Role: "group:member", Object: string(eng), ExpiresAt: timestamppb.New(time.Now().Add(time.Hour))},
+		{Subject: "group:eng#admin", Role: "item:deployer", Object: string(api)},
+		{Subject: string(bob), Role: "item:deployer", Object: string(api), Condition: "mfa"},
+	} {
+		_, err := src.Grant(ctx, req)
+		require.NoError(t, err)
+	}
+
+	export := func(s *Doorman) []*pb.Record {
+		stream := &exportStream{}
+		require.NoError(t, s.Export(&pb.ExportRequest{}, stream))
+		return stream.records
+	}
+	load := func(s *Doorman, mode pb.ImportMode, records []*pb.Record) (*pb.ImportResponse, error) {
+		stream := &importStream{}
+		for _, r := range records {
+			stream.requests = append(stream.requests, &pb.ImportRequest{Mode: mode, Record: r})
+		}
+		err := s.Import(stream)
+		return stream.res, err
+	}
+
+	records := export(src)
+	require.Len(t, records, 4+3+4)
+	assert.NotNil(t, records[0].GetType())
+	assert.NotNil(t, records[4].GetRole())
+	assert.NotNil(t, records[7].GetTuple())
+
+	dst := NewDoorman(openStore())
+
+	t.Run("Import into an empty store", func(t *testing.T) {
+		res, err := load(dst, pb.ImportMode_MERGE, records)
+		require.NoError(t, err)
+		// group is registered in a new store already, see db.DefaultTypes
+		assert.Equal(t, []int32{3, 3, 4}, []int32{res.Types, res.Roles, res.Tuples})
+		assert.NotNil(t, res.ConsistencyToken)
+
+		assert.True(t, check(dst, alice, "deploy", api).Success)
+		assert.False(t, check(dst, bob, "manage", eng).Success)
+		assert.Equal(t, len(records), len(export(dst)))
+	})
+
+	t.Run("Merging again adds nothing", func(t *testing.T) {
+		res, err := load(dst, pb.ImportMode_MERGE, records)
+		require.NoError(t, err)
+		assert.Equal(t, []int32{0, 0, 0}, []int32{res.Types, res.Roles, res.Tuples})
+		assert.Nil(t, res.ConsistencyToken)
+	})
+
+	t.Run("Failure: merging a conflicting role imports nothing", func(t *testing.T) {
+		_, err := load(dst, pb.ImportMode_MERGE, []*pb.Record{
+			{Record: &pb.Record_Tuple{Tuple: &pb.Tuple{Subject: string(bob), Role: "group:admin", Object: string(eng)}}},
+			{Record: &pb.Record_Role{Role: &pb.Role{Id: "item:deployer", Verbs: []string{"deploy", "delete"}}}},
+		})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.False(t, check(dst, bob, "manage", eng).Success)
+	})
+
+	t.Run("Failure: invalid records", func(t *testing.T) {
+		_, err := load(dst, pb.ImportMode_MERGE, []*pb.Record{
+			{Record: &pb.Record_Tuple{Tuple: &pb.Tuple{Subject: "unknown:x", Role: "group:member", Object: string(eng)}}},
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = load(dst, pb.ImportMode_MERGE, []*pb.Record{{}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		// Everything but the tuple of the subject set
+		replaced := append(slices.Clone(records[:7]), records[8:]...)
+		res, err := load(dst, pb.ImportMode_REPLACE, replaced)
+		require.NoError(t, err)
+		assert.Equal(t, []int32{4, 3, 3}, []int32{res.Types, res.Roles, res.Tuples})
+
+		assert.True(t, check(dst, alice, "manage", eng).Success)
+		assert.False(t, check(dst, alice, "deploy", api).Success)
+		assert.Equal(t, len(replaced), len(export(dst)))
+	})
+
+	t.Run("More records than a batch", func(t *testing.T) {
+		many := slices.Clone(records[:7])
+		for i := 0; i < importBatchSize+1; i++ {
+			tuple := &pb.Tuple{Subject: fmt.Sprintf("user:%d", i), Role: "item:deployer", Object: string(api)}
+			many = append(many, &pb.Record{Record: &pb.Record_Tuple{Tuple: tuple}})
+		}
+		res, err := load(dst, pb.ImportMode_REPLACE, many)
+		require.NoError(t, err)
+		assert.Equal(t, int32(importBatchSize+1), res.Tuples)
+		assert.Equal(t, len(many), len(export(dst)))
+	})
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	s := NewDoorman(newStore())
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	carol := doorman.Object("user:carol")
+	item1 := doorman.Object("item:1")
+	item2 := doorman.Object("item:2")
+
+	_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"read"}})
+	require.NoError(t, err)
+	for _, req := range []*pb.GrantRequest{
+		{Subject: string(alice), Role: "item:viewer", Object: string(item1)},
+		{Subject: string(bob), Role: "item:viewer", Object: string(item2)},
+	} {
+		_, err := s.Grant(ctx, req)
+		require.NoError(t, err)
+	}
+
+	manifest := &pb.Manifest{
+		Roles: []*pb.Role{
+			{Id: "item:editor", Verbs: []string{"write"}, Includes: []string{"item:viewer"}},
+			{Id: "group:member", Verbs: []string{"inherits"}},
+			{Id: "item:viewer", Verbs: []string{"read"}},
+		},
+		Tuples: []*pb.Tuple{
+			{Subject: string(alice), Role: "item:viewer", Object: string(item1)},
+			{Subject: string(carol), Role: "item:editor", Object: string(item1)},
+			{Subject: string(bob), Role: "item:viewer", Object: string(item2), Condition: "mfa"},
+		},
+	}
+
+	diffs := func(res *pb.ApplyResponse) []string {
+		diffs := []string{}
+		for _, d := range res.Diffs {
+			switch item := d.Item.(type) {
+			case *pb.Diff_Role:
+				diffs = append(diffs, d.Type.String()+" "+item.Role.Id)
+			case *pb.Diff_Tuple:
+				diffs = append(diffs, d.Type.String()+" "+item.Tuple.Subject+" "+item.Tuple.Role+" "+item.Tuple.Object)
+			}
+		}
+		return diffs
+	}
+	expected := []string{
+		"ADDED item:editor",
+		"ADDED group:member",
+		"ADDED user:carol item:editor item:1",
+		"CHANGED user:bob item:viewer item:2",
+	}
+
+	t.Run("Dry run", func(t *testing.T) {
+		res, err := s.Apply(ctx, &pb.ApplyRequest{Manifest: manifest, DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, expected, diffs(res))
+		assert.Nil(t, res.ConsistencyToken)
+
+		assert.False(t, check(s, carol, "write", item1).Success)
+		_, err = s.roles.Retrieve(ctx, "item:editor")
+		assert.ErrorIs(t, err, db.ErrInvalidRole)
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		res, err := s.Apply(ctx, &pb.ApplyRequest{Manifest: manifest})
+		require.NoError(t, err)
+		assert.Equal(t, expected, diffs(res))
+		assert.NotNil(t, res.ConsistencyToken)
+
+		assert.True(t, check(s, carol, "write", item1).Success)
+		assert.True(t, check(s, carol, "read", item1).Success)
+		assert.Equal(t, pb.Decision_MISSING_CONTEXT, check(s, bob, "read", item2).Decision)
+
+		res, err = s.Apply(ctx, &pb.ApplyRequest{Manifest: manifest})
+		require.NoError(t, err)
+		assert.Empty(t, diffs(res))
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		pruned := &pb.Manifest{Roles: manifest.Roles[:1], Tuples: manifest.Tuples[:2]}
+		pruned.Roles = append(pruned.Roles, manifest.Roles[2])
+
+		res, err := s.Apply(ctx, &pb.ApplyRequest{Manifest: pruned, Prune: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"REMOVED user:bob item:viewer item:2", "REMOVED group:member"}, diffs(res))
+
+		assert.False(t, check(s, bob, "read", item2).Success)
+		assert.True(t, check(s, alice, "read", item1).Success)
+	})
+
+	t.Run("Failure: invalid manifests change nothing", func(t *testing.T) {
+		_, err := s.Apply(ctx, &pb.ApplyRequest{Prune: true, Manifest: &pb.Manifest{
+			Tuples: []*pb.Tuple{{Subject: string(bob), Role: "item:viewer", Object: string(item2)}},
+		}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = s.Apply(ctx, &pb.ApplyRequest{Manifest: &pb.Manifest{
+			Roles: []*pb.Role{
+				{Id: "item:viewer", Verbs: []string{"read", "list"}},
+				{Id: "item:owner", Verbs: []string{"own"}, Includes: []string{"item:unknown"}},
+			},
+			Tuples: []*pb.Tuple{{Subject: string(bob), Role: "item:viewer", Object: string(item2)}},
+		}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		assert.False(t, check(s, alice, "list", item1).Success)
+		assert.False(t, check(s, bob, "read", item2).Success)
+	})
+}
+
+func TestTenants(t *testing.T) {
+	ctx := context.Background()
+	s := NewDoorman(newStore())
+	acmeCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(TenantMetadataKey, "acme"))
+
+	alice := doorman.Object("user:alice")
+	item1 := doorman.Object("item:1")
+
+	for _, id := range []string{"user", "item"} {
+		_, err := s.UpsertType(acmeCtx, &pb.UpsertTypeRequest{Id: id})
+		require.NoError(t, err)
+	}
+
+	// The same role means different things to each tenant
+	_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"read"}})
+	require.NoError(t, err)
+	_, err = s.UpsertRole(acmeCtx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"write"}})
+	require.NoError(t, err)
+
+	_, err = s.Grant(acmeCtx, &pb.GrantRequest{Subject: string(alice), Role: "item:viewer", Object: string(item1)})
+	require.NoError(t, err)
+
+	acme, err := s.forTenant(acmeCtx)
+	require.NoError(t, err)
+	processAllChanges(acme)
+
+	t.Run("Checks only see the tuples and roles of the tenant", func(t *testing.T) {
+		res, err := s.Check(acmeCtx, &pb.CheckRequest{Subject: string(alice), Verb: "write", Object: string(item1)})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+
+		res, err = s.Check(acmeCtx, &pb.CheckRequest{Subject: string(alice), Verb: "read", Object: string(item1)})
+		require.NoError(t, err)
+		assert.False(t, res.Success)
+
+		assert.False(t, check(s, alice, "read", item1).Success)
+		assert.False(t, check(s, alice, "write", item1).Success)
+	})
+
+	t.Run("Types are separate", func(t *testing.T) {
+		res, err := s.ListTypes(acmeCtx, &pb.ListTypesRequest{})
+		require.NoError(t, err)
+		assert.Len(t, res.Items, 2)
+
+		_, err = s.Check(acmeCtx, &pb.CheckRequest{Subject: string(alice), Verb: "read", Object: "group:admins"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("RebuildCache only rebuilds the tenant", func(t *testing.T) {
+		_, err := s.RebuildCache(acmeCtx, &pb.RebuildCacheRequest{})
+		require.NoError(t, err)
+
+		pending := "pending"
+		changes, err := s.changes.List(ctx, db.ChangeFilter{Status: &pending})
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+
+		// The two types, the role and the grant
+		changes, err = acme.changes.List(ctx, db.ChangeFilter{Status: &pending})
+		require.NoError(t, err)
+		assert.Len(t, changes, 4)
+
+		processAllChanges(acme)
+		res, err := s.Check(acmeCtx, &pb.CheckRequest{Subject: string(alice), Verb: "write", Object: string(item1)})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+	})
+
+	t.Run("Failure: invalid tenant", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(ctx, metadata.Pairs(TenantMetadataKey, "Acme Inc"))
+		_, err := s.ListRoles(ctx, &pb.ListRolesRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestAuthorization(t *testing.T) {
+	ctx := context.Background()
+	s := NewDoorman(newStore())
+
+	require.NoError(t, s.Bootstrap(ctx, []doorman.Object{"user:alice"}))
+	s.EnableAuthorization()
+
+	// authorize waits for the changes to be applied
+	processing, stop := context.WithCancel(ctx)
+	defer stop()
+	go func() {
+		for processing.Err() == nil {
+			s.ProcessChange()
+		}
+	}()
+
+	alice := withPrincipal(ctx, &Principal{ID: "alice"})
+	bob := withPrincipal(ctx, &Principal{ID: "user:bob"})
+
+	// Team leads can manage the members of their own group, but not edit roles
+	_, err := s.UpsertRole(alice, &pb.UpsertRoleRequest{Id: "group:lead", Verbs: []string{ManageVerb}})
+	require.NoError(t, err)
+	_, err = s.UpsertRole(alice, &pb.UpsertRoleRequest{Id: "group:member", Verbs: []string{"read"}})
+	require.NoError(t, err)
+	_, err = s.Grant(alice, &pb.GrantRequest{Subject: "user:bob", Role: "group:lead", Object: "group:eng"})
+	require.NoError(t, err)
+
+	t.Run("Success: managing the object", func(t *testing.T) {
+		_, err := s.Grant(bob, &pb.GrantRequest{Subject: "user:carol", Role: "group:member", Object: "group:eng"})
+		require.NoError(t, err)
+		_, err = s.Revoke(bob, &pb.RevokeRequest{Subject: "user:carol", Role: "group:member", Object: "group:eng"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Failure: not managing the object", func(t *testing.T) {
+		_, err := s.Grant(bob, &pb.GrantRequest{Subject: "user:carol", Role: "group:member", Object: "group:ops"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Failure: not managing the subject whose access the tuple changes", func(t *testing.T) {
+		// Managing an item is passed on to the ones in it
+		_, err := s.UpsertRole(alice, &pb.UpsertRoleRequest{Id: "item:owner", Verbs: []string{ManageVerb}})
+		require.NoError(t, err)
+		_, err = s.UpsertRole(alice, &pb.UpsertRoleRequest{Id: "item:parent", Verbs: []string{ManageVerb}, Parent: true})
+		require.NoError(t, err)
+		for _, item := range []string{"item:box", "item:toy"} {
+			_, err = s.Grant(alice, &pb.GrantRequest{Subject: "user:bob", Role: "item:owner", Object: item})
+			require.NoError(t, err)
+		}
+
+		// Otherwise bob would manage doorman itself
+		_, err = s.Grant(bob, &pb.GrantRequest{Subject: string(SystemObject), Role: "item:parent", Object: "item:box"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = s.Grant(bob, &pb.GrantRequest{Subject: "item:toy", Role: "item:parent", Object: "item:box"})
+		require.NoError(t, err)
+
+		// The members of a group or subject set get the role too
+		_, err = s.Grant(bob, &pb.GrantRequest{Subject: "group:ops", Role: "group:member", Object: "group:eng"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = s.Grant(bob, &pb.GrantRequest{Subject: "group:ops#member", Role: "item:owner", Object: "item:box"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = s.Grant(bob, &pb.GrantRequest{Subject: "group:eng#member", Role: "item:owner", Object: "item:box"})
+		require.NoError(t, err)
+
+		_, err = s.Revoke(bob, &pb.RevokeRequest{Subject: string(SystemObject), Role: "item:parent", Object: "item:box"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Failure: not managing doorman:system", func(t *testing.T) {
+		_, err := s.UpsertRole(bob, &pb.UpsertRoleRequest{Id: "group:member", Verbs: []string{"read", "write"}})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = s.RemoveRole(bob, &pb.RemoveRoleRequest{Id: "group:member"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = s.Apply(bob, &pb.ApplyRequest{Manifest: &pb.Manifest{}, Prune: true})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Failure: no principal", func(t *testing.T) {
+		_, err := s.Grant(ctx, &pb.GrantRequest{Subject: "user:carol", Role: "group:member", Object: "group:eng"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Admins of the default tenant manage every tenant", func(t *testing.T) {
+		md := metadata.Pairs(TenantMetadataKey, "acme")
+		_, err := s.UpsertType(metadata.NewIncomingContext(alice, md), &pb.UpsertTypeRequest{Id: "user"})
+		require.NoError(t, err)
+		_, err = s.UpsertType(metadata.NewIncomingContext(bob, md), &pb.UpsertTypeRequest{Id: "group"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Bootstrapping again changes nothing", func(t *testing.T) {
+		before, err := s.changes.LastSeq(ctx)
+		require.NoError(t, err)
+		require.NoError(t, s.Bootstrap(ctx, []doorman.Object{"user:alice"}))
+
+		after, err := s.changes.LastSeq(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("Bootstrapping revokes the admins that are no longer configured", func(t *testing.T) {
+		require.NoError(t, s.Bootstrap(ctx, []doorman.Object{"user:alice", "user:dave"}))
+		dave := withPrincipal(ctx, &Principal{ID: "dave"})
+		_, err := s.UpsertRole(dave, &pb.UpsertRoleRequest{Id: "group:member", Verbs: []string{"read"}})
+		require.NoError(t, err)
+
+		require.NoError(t, s.Bootstrap(ctx, []doorman.Object{"user:alice"}))
+		_, err = s.UpsertRole(dave, &pb.UpsertRoleRequest{Id: "group:member", Verbs: []string{"read"}})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
+
+func TestCheckAt(t *testing.T) {
+	ctx := context.Background()
+	s := NewDoorman(newStore())
+
+	alice := doorman.Object("user:alice")
+	bob := doorman.Object("user:bob")
+	eng := doorman.Object("group:eng")
+	item1 := doorman.Object("item:1")
+
+	// Leaves a gap between the instants, as the stores may not keep the time more precisely than milliseconds
+	instant := func() time.Time {
+		time.Sleep(10 * time.Millisecond)
+		defer time.Sleep(10 * time.Millisecond)
+		return time.Now()
+	}
+
+	checkAt := func(sub doorman.Object, verb string, at time.Time) *pb.CheckResponse {
+		res, err := s.CheckAt(ctx, &pb.CheckAtRequest{Subject: string(sub), Verb: verb, Object: string(item1), At: timestamppb.New(at)})
+		require.NoError(t, err)
+		return res
+	}
+
+	// Registered by newStore without a change, so they are recorded again
+	for _, id := range []string{"user", "group", "item"} {
+		_, err := s.UpsertType(ctx, &pb.UpsertTypeRequest{Id: id, Nestable: id == "group"})
+		require.NoError(t, err)
+	}
+	_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "group:member", Verbs: []string{"inherits"}})
+	require.NoError(t, err)
+	_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"read"}})
+	require.NoError(t, err)
+	beforeGrants := instant()
+
+	_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: "group:member", Object: string(eng)})
+	require.NoError(t, err)
+	granted, err := s.Grant(ctx, &pb.GrantRequest{Subject: string(eng), Role: "item:viewer", Object: string(item1)})
+	require.NoError(t, err)
+	_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(bob), Role: "item:viewer", Object: string(item1), ExpiresAt: timestamppb.New(time.Now().Add(100 * time.Millisecond))})
+	require.NoError(t, err)
+	afterGrants := instant()
+
+	_, err = s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"write"}})
+	require.NoError(t, err)
+	afterRoleChange := instant()
+
+	_, err = s.Revoke(ctx, &pb.RevokeRequest{Subject: string(alice), Role: "group:member", Object: string(eng)})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	afterRevoke := instant()
+
+	t.Run("Before the grants", func(t *testing.T) {
+		assert.False(t, checkAt(alice, "read", beforeGrants).Success)
+	})
+
+	t.Run("After the grants, with the path", func(t *testing.T) {
+		res := checkAt(alice, "read", afterGrants)
+		assert.Equal(t, pb.Decision_ALLOWED, res.Decision)
+		require.Len(t, res.Path, 2)
+		assert.Equal(t, string(eng), res.Path[0].Object)
+		assert.Equal(t, string(item1), res.Path[1].Object)
+		assert.Equal(t, "item:viewer", res.Role.Id)
+
+		assert.True(t, checkAt(bob, "read", afterGrants).Success)
+	})
+
+	t.Run("The instant of a change includes it", func(t *testing.T) {
+		change, err := s.changes.Retrieve(ctx, granted.ConsistencyToken)
+		require.NoError(t, err)
+		assert.True(t, checkAt(alice, "read", change.CreatedAt).Success)
+	})
+
+	t.Run("The roles as they were", func(t *testing.T) {
+		assert.False(t, checkAt(alice, "read", afterRoleChange).Success)
+		assert.True(t, checkAt(alice, "write", afterRoleChange).Success)
+	})
+
+	t.Run("After the revoke and the expiry", func(t *testing.T) {
+		assert.False(t, checkAt(alice, "write", afterRevoke).Success)
+		assert.False(t, checkAt(bob, "write", afterRevoke).Success)
+	})
+
+	t.Run("Failure: in the future", func(t *testing.T) {
+		_, err := s.CheckAt(ctx, &pb.CheckAtRequest{Subject: string(alice), Verb: "read", Object: string(item1), At: timestamppb.New(time.Now().Add(time.Hour))})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Failure: before the types were recorded", func(t *testing.T) {
+		s := NewDoorman(newStore())
+		_, err := s.UpsertRole(ctx, &pb.UpsertRoleRequest{Id: "item:viewer", Verbs: []string{"read"}})
+		require.NoError(t, err)
+		_, err = s.Grant(ctx, &pb.GrantRequest{Subject: string(alice), Role: "item:viewer", Object: string(item1)})
+		require.NoError(t, err)
+		beforeRecorded := instant()
+
+		for _, id := range []string{"user", "item"} {
+			_, err := s.UpsertType(ctx, &pb.UpsertTypeRequest{Id: id})
+			require.NoError(t, err)
+		}
+		afterRecorded := instant()
+
+		_, err = s.CheckAt(ctx, &pb.CheckAtRequest{Subject: string(alice), Verb: "read", Object: string(item1), At: timestamppb.New(beforeRecorded)})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		res, err := s.CheckAt(ctx, &pb.CheckAtRequest{Subject: string(alice), Verb: "read", Object: string(item1), At: timestamppb.New(afterRecorded)})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+	})
+}
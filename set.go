@@ -3,6 +3,8 @@ package doorman
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 )
 
 type Set struct {
@@ -18,31 +20,55 @@ func NewSet(o Object, verb Verb) Set {
 	return Set{Object: o, Verb: verb}
 }
 
+// Denied is the set of subjects that can't have the verb on the object, even if they are in s.
+// It is cached the same way as s, so denies are inherited through groups too.
+func (s Set) Denied() Set {
+	return Set{Object: s.Object, Verb: "!" + s.Verb}
+}
+
+func (s Set) IsDenied() bool {
+	return strings.HasPrefix(string(s.Verb), "!")
+}
+
+// Membership of a set, which ends at ExpiresAt unless it is nil.
+// Conditions have to hold for it to apply, see Condition.
+type Membership struct {
+	Set        Set
+	ExpiresAt  *time.Time
+	Conditions []string
+}
+
+func (m Membership) Unconditional() bool {
+	return len(m.Conditions) == 0
+}
+
 type resolveRole func(ctx context.Context, id string) (*Role, error)
 
-func ParentTuplesToSets(ctx context.Context, tuples []Tuple, r resolveRole) ([]Set, error) {
-	uniqueRoles := map[string]*Role{}
-	for _, t := range tuples {
-		uniqueRoles[t.Role] = nil
+// RoleSets lists the sets the role on obj makes its subjects a member of, or the denied sets for a deny role.
+// Parent roles don't make their subjects a member of any.
+func RoleSets(ctx context.Context, id string, obj Object, r resolveRole) ([]Set, error) {
+	role, err := r(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-
-	for id := range uniqueRoles {
-		role, err := r(ctx, id)
-		if err != nil {
-			return nil, fmt.Errorf("resolving role failed: %w", err)
-		}
-		uniqueRoles[id] = role
+	if role.Parent {
+		return nil, nil
+	}
+	verbs, err := ResolveVerbs(ctx, id, r)
+	if err != nil {
+		return nil, err
 	}
 
-	sets := []Set{}
-	for _, t := range tuples {
-		for _, verb := range uniqueRoles[t.Role].Verbs {
-			sets = append(sets, Set{
-				Object: t.Object,
-				Verb:   verb,
-			})
+	sets := make([]Set, len(verbs))
+	for i, verb := range verbs {
+		sets[i] = Set{Object: obj, Verb: verb}
+		if role.Deny {
+			sets[i] = sets[i].Denied()
 		}
 	}
-
+	// The holders of the role are members of its subject set, see Object.SubjectSet
+	if subjectSet, ok := obj.SubjectSet(id); ok && !role.Deny {
+		sets = append(sets, Set{Object: subjectSet, Verb: "inherits"})
+	}
 	return sets, nil
 }
@@ -2,7 +2,7 @@ package doorman
 
 import (
 	"fmt"
-
+	"time"
 )
 
 type Tuple struct {
@@ -10,6 +10,10 @@ type Tuple struct {
 	Role    string `json:"role"`
 	Object  Object `json:"object"`
 	Path    Path   `json:"path"`
+	// nil if the tuple does not expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// the tuple only applies when it holds, see Condition
+	Condition string `json:"condition,omitempty"`
 }
 
 func (t Tuple) Equal(r Tuple) bool {
@@ -20,29 +24,64 @@ func (t Tuple) String() string {
 	return fmt.Sprintf("(%s, %s, %s)", t.Subject, t.Role, t.Object)
 }
 
+func (t Tuple) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
 func NewTuple(sub Object, role string, obj Object) Tuple {
-	return Tuple{sub, role, obj, Path{}}
+	return Tuple{Subject: sub, Role: role, Object: obj, Path: Path{}}
 }
 
 type Connection struct {
-	Role   string `json:"connection"`
-	Object Object `json:"object"`
+	// empty between an object and a subject set of it, e.g. group:eng and group:eng#maintainer
+	Role      string     `json:"connection"`
+	Object    Object     `json:"object"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Condition string     `json:"condition,omitempty"`
 }
 
 type Path []Connection
 
-// e.g. user:alice -> item:1 -> item:2 should not connect user:alice with item:2
-// why? because it's not a group
-func (path Path) GroupsOnly() bool {
-	for _, conn := range path {
-		if conn.Object.Type() != "group" {
-			return false
+// Inverted turns a path listed from obj by an inverted ListConnected around, so it leads from its subject to obj.
+func (path Path) Inverted(obj Object) Path {
+	inverted := make(Path, len(path))
+	for i, conn := range path {
+		conn.Object = obj
+		if i > 0 {
+			conn.Object = path[i-1].Object
 		}
+		inverted[len(path)-1-i] = conn
 	}
-
-	return true
+	return inverted
 }
 
 func (path Path) Object() Object {
 	return path[len(path)-1].Object
 }
+
+// ExpiresAt is when the first connection on the path expires, nil if none of them do.
+func (path Path) ExpiresAt() *time.Time {
+	var earliest *time.Time
+	for _, conn := range path {
+		if conn.ExpiresAt != nil && (earliest == nil || conn.ExpiresAt.Before(*earliest)) {
+			earliest = conn.ExpiresAt
+		}
+	}
+	return earliest
+}
+
+// Conditions lists the conditions of the connections that have one.
+func (path Path) Conditions() []string {
+	conditions := []string{}
+	for _, conn := range path {
+		if conn.Condition != "" {
+			conditions = append(conditions, conn.Condition)
+		}
+	}
+	return conditions
+}
+
+func (path Path) Expired(now time.Time) bool {
+	expiresAt := path.ExpiresAt()
+	return expiresAt != nil && !now.Before(*expiresAt)
+}